@@ -4,6 +4,9 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"regexp"
+	"strconv"
+	"strings"
 )
 
 // Red, green and blue multipliers to be used on perceived brightness calculations
@@ -52,6 +55,39 @@ func HTMLToRGBAlt(in string) *RGB {
 	return &rgb
 }
 
+// Matches rgb(r, g, b) with 0..255 components
+var rgbFuncRegex = regexp.MustCompile(`^rgb\(\s*(\d{1,3})\s*,\s*(\d{1,3})\s*,\s*(\d{1,3})\s*\)$`)
+
+// ParseColor - accepts black, white, #rgb, #rrggbb (hash optional) or rgb(r, g, b)
+func ParseColor(in string) (RGB, error) {
+	in = strings.ToLower(strings.TrimSpace(in))
+	switch in {
+	case "black":
+		return RGB{R: 0, G: 0, B: 0}, nil
+	case "white":
+		return RGB{R: 255, G: 255, B: 255}, nil
+	}
+	if m := rgbFuncRegex.FindStringSubmatch(in); m != nil {
+		var ret [3]float64
+		for i := range ret {
+			v, _ := strconv.Atoi(m[i+1])
+			if v > 255 {
+				return RGB{}, fmt.Errorf("Invalid color component %d", v)
+			}
+			ret[i] = float64(v)
+		}
+		return RGB{R: ret[0], G: ret[1], B: ret[2]}, nil
+	}
+	hex := strings.TrimPrefix(in, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 || strings.Trim(hex, "0123456789abcdef") != "" {
+		return RGB{}, fmt.Errorf("Invalid color %s", in)
+	}
+	return HTMLToRGB(hex)
+}
+
 // A nudge to make truncation round to nearest number instead of flooring
 const delta = 1 / 512.0
 
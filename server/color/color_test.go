@@ -77,6 +77,55 @@ func TestRGBtoHTML(t *testing.T) {
 	}
 }
 
+func TestParseColor(t *testing.T) {
+	valid := map[string]string{
+		"black":              "#000000",
+		"White":              "#ffffff",
+		"#884444":            "#884444",
+		"884444":             "#884444",
+		"#ABC":               "#aabbcc",
+		"rgb(136, 68, 68)":   "#884444",
+		"rgb(0,255,0)":       "#00ff00",
+		" rgb( 1 , 2 , 3 ) ": "#010203",
+	}
+	for in, expected := range valid {
+		rgb, err := ParseColor(in)
+		if err != nil {
+			t.Errorf("Expected %s but got error %s", expected, err)
+		} else if rgb.ToHTML(true) != expected {
+			t.Errorf("Expected %s but got %s", expected, rgb.ToHTML(true))
+		}
+	}
+	for _, in := range []string{"", "#12", "#12345g", "rgb(256, 0, 0)", "rgb(1,2)", "red"} {
+		if _, err := ParseColor(in); err == nil {
+			t.Errorf("Expected error for %s", in)
+		}
+	}
+}
+
+func TestColorName(t *testing.T) {
+	expected := map[string]string{
+		"#000000": "black",
+		"#ffffff": "white",
+		"#808080": "gray",
+		"#06c2b5": "teal",
+		"#1e3cff": "blue",
+		"#0a1a66": "dark blue",
+		"#ff2020": "red",
+		"#ffb0b0": "pink",
+		"#8b4513": "brown",
+		"#ffd700": "yellow",
+		"#a0ffa0": "light green",
+		"#9b30ff": "purple",
+	}
+	for html, name := range expected {
+		rgb, _ := HTMLToRGB(html)
+		if rgb.Name() != name {
+			t.Errorf("Expected %s for %s but got %s", name, html, rgb.Name())
+		}
+	}
+}
+
 func TestRGBtoHSB(t *testing.T) {
 	expectedHSB := HSB{
 		H: 0.0,
@@ -223,3 +272,115 @@ func TestPercevedBrightness(t *testing.T) {
 		t.Errorf("Wrong perceived brightness expected %f got %f", expectedPercevedB, hasPB)
 	}
 }
+
+func TestToOKLab(t *testing.T) {
+	white := RGB{R: 255.0, G: 255.0, B: 255.0}.ToOKLab()
+	if math.Abs(white.L-1) > 1e-4 || math.Abs(white.A) > 1e-4 || math.Abs(white.B) > 1e-4 {
+		t.Errorf("Expected white to be L 1 a 0 b 0 but got %v", white)
+	}
+	// Reference value from the OKLab paper's implementation
+	red := RGB{R: 255.0, G: 0.0, B: 0.0}.ToOKLab()
+	expected := OKLab{L: 0.62796, A: 0.22486, B: 0.12585}
+	if red.Distance(expected) > 1e-4 {
+		t.Errorf("Expected red to be %v but got %v", expected, red)
+	}
+	if d := white.Distance(RGB{}.ToOKLab()); math.Abs(d-1) > 1e-4 {
+		t.Errorf("Expected black and white to be 1 apart but got %f", d)
+	}
+}
+
+func TestPerceptualRoundTrips(t *testing.T) {
+	for r := 0.0; r <= 255; r += 51 {
+		for g := 0.0; g <= 255; g += 51 {
+			for b := 0.0; b <= 255; b += 51 {
+				rgb := RGB{R: r, G: g, B: b}
+				for name, converted := range map[string]RGB{
+					"OKLab": rgb.ToOKLab().ToRGB(),
+					"OKLCH": rgb.ToOKLCH().ToRGB(),
+					"Lab":   rgb.ToLab().ToRGB(),
+				} {
+					if math.Abs(converted.R-r) > 0.01 || math.Abs(converted.G-g) > 0.01 || math.Abs(converted.B-b) > 0.01 {
+						t.Errorf("Expected %v back from %s but got %v", rgb, name, converted)
+					}
+				}
+				if !rgb.ToOKLCH().InGamut() {
+					t.Errorf("Expected %v to be in gamut", rgb)
+				}
+			}
+		}
+	}
+	if (OKLCH{L: 0.9, C: 0.4, H: 30}).InGamut() {
+		t.Errorf("Expected a light, very saturated red to be out of gamut")
+	}
+}
+
+func TestToLab(t *testing.T) {
+	lab := RGB{R: 255.0, G: 0.0, B: 0.0}.ToLab()
+	expected := Lab{L: 53.2408, A: 80.0925, B: 67.2032}
+	if math.Abs(lab.L-expected.L) > 0.01 || math.Abs(lab.A-expected.A) > 0.01 || math.Abs(lab.B-expected.B) > 0.01 {
+		t.Errorf("Expected %v but got %v", expected, lab)
+	}
+}
+
+func TestDeltaE2000(t *testing.T) {
+	// Pairs from Sharma, Wu and Dalal's CIEDE2000 test data
+	pairs := []struct {
+		a, b     Lab
+		expected float64
+	}{
+		{Lab{50.0000, 2.6772, -79.7751}, Lab{50.0000, 0.0000, -82.7485}, 2.0425},
+		{Lab{50.0000, 3.1571, -77.2803}, Lab{50.0000, 0.0000, -82.7485}, 2.8615},
+		{Lab{50.0000, 2.8361, -74.0200}, Lab{50.0000, 0.0000, -82.7485}, 3.4412},
+		{Lab{50.0000, 0.0000, 0.0000}, Lab{50.0000, -1.0000, 2.0000}, 2.3669},
+		{Lab{50.0000, 2.5000, 0.0000}, Lab{73.0000, 25.0000, -18.0000}, 27.1492},
+		{Lab{60.2574, -34.0099, 36.2677}, Lab{60.4626, -34.1751, 39.4387}, 1.2644},
+	}
+	for _, p := range pairs {
+		if got := p.a.DeltaE2000(p.b); math.Abs(got-p.expected) > 1e-4 {
+			t.Errorf("Expected %f between %v and %v but got %f", p.expected, p.a, p.b, got)
+		}
+		if got := p.b.DeltaE2000(p.a); math.Abs(got-p.expected) > 1e-4 {
+			t.Errorf("Expected DeltaE2000 to be symmetric, %f but got %f", p.expected, got)
+		}
+	}
+}
+
+func TestContrastRatio(t *testing.T) {
+	black := RGB{R: 0, G: 0, B: 0}
+	white := RGB{R: 255, G: 255, B: 255}
+	if ratio := black.ContrastRatio(white); math.Abs(ratio-21) > 1e-9 {
+		t.Errorf("Expected 21 but got %f", ratio)
+	}
+	if ratio := white.ContrastRatio(white); ratio != 1 {
+		t.Errorf("Expected 1 but got %f", ratio)
+	}
+	// #767676 is the lightest gray that passes AA on white
+	gray := RGB{R: 0x76, G: 0x76, B: 0x76}
+	if ratio := gray.ContrastRatio(white); ratio < ContrastAA || ratio > 4.6 {
+		t.Errorf("Expected just over %f but got %f", ContrastAA, ratio)
+	}
+}
+
+func TestSimulateCVD(t *testing.T) {
+	gray := RGB{R: 128, G: 128, B: 128}
+	red := RGB{R: 200, G: 40, B: 40}
+	green := RGB{R: 40, G: 150, B: 40}
+	for _, d := range Deficiencies {
+		// Machado's matrices leave grays alone
+		if sim := gray.SimulateCVD(d); math.Abs(sim.R-gray.R) > 1 || math.Abs(sim.G-gray.G) > 1 || math.Abs(sim.B-gray.B) > 1 {
+			t.Errorf("Expected %s to keep %v but got %v", d, gray, sim)
+		}
+	}
+	normal := red.DeltaE2000(green)
+	for _, d := range []Deficiency{Protan, Deutan} {
+		if simulated := red.SimulateCVD(d).DeltaE2000(green.SimulateCVD(d)); simulated > normal/2 {
+			t.Errorf("Expected red and green to get much closer under %s, %f vs %f", d, simulated, normal)
+		}
+	}
+	if d, err := ParseDeficiency("Deuteranopia"); err != nil || d != Deutan {
+		t.Errorf("Expected %s but got %s", Deutan, d)
+	}
+	if _, err := ParseDeficiency("none"); err == nil {
+		t.Errorf("Expected error for unknown deficiency")
+	}
+}
@@ -0,0 +1,20 @@
+package color
+
+// Contrast ratios WCAG 2 requires for text
+const ContrastAA = 4.5
+const ContrastAALarge = 3.0
+const ContrastAAA = 7.0
+
+// RelativeLuminance - WCAG 2 relative luminance, 0 for black and 1 for white
+func (c RGB) RelativeLuminance() float64 {
+	return 0.2126*srgbToLinear(c.R) + 0.7152*srgbToLinear(c.G) + 0.0722*srgbToLinear(c.B)
+}
+
+// ContrastRatio - WCAG 2 contrast ratio between two colors, 1 to 21
+func (c RGB) ContrastRatio(other RGB) float64 {
+	l1, l2 := c.RelativeLuminance(), other.RelativeLuminance()
+	if l1 < l2 {
+		l1, l2 = l2, l1
+	}
+	return (l1 + 0.05) / (l2 + 0.05)
+}
@@ -0,0 +1,64 @@
+package color
+
+import (
+	"errors"
+	"math"
+	"strings"
+)
+
+// Deficiency - a kind of color vision deficiency
+type Deficiency string
+
+const (
+	Protan Deficiency = "protan" // Missing or weak red cones
+	Deutan Deficiency = "deutan" // Missing or weak green cones
+	Tritan Deficiency = "tritan" // Missing or weak blue cones
+)
+
+// Deficiencies - every deficiency that can be simulated
+var Deficiencies = []Deficiency{Protan, Deutan, Tritan}
+
+// Full severity simulation matrices in linear RGB from Machado, Oliveira and Fernandes (2009)
+var cvdMatrices = map[Deficiency][3][3]float64{
+	Protan: {
+		{0.152286, 1.052583, -0.204868},
+		{0.114503, 0.786281, 0.099216},
+		{-0.003882, -0.048116, 1.051998},
+	},
+	Deutan: {
+		{0.367322, 0.860646, -0.227968},
+		{0.280085, 0.672501, 0.047413},
+		{-0.011820, 0.042940, 0.968881},
+	},
+	Tritan: {
+		{1.255528, -0.076749, -0.178779},
+		{-0.078411, 0.930809, 0.147602},
+		{0.004733, 0.691367, 0.303900},
+	},
+}
+
+// ParseDeficiency - deficiency by name, protanopia and the like are accepted too
+func ParseDeficiency(name string) (Deficiency, error) {
+	name = strings.ToLower(name)
+	for _, d := range Deficiencies {
+		if strings.HasPrefix(name, string(d)[:4]) {
+			return d, nil
+		}
+	}
+	return "", errors.New("Valid deficiencies are 'protan', 'deutan' or 'tritan'")
+}
+
+// SimulateCVD - how c looks to someone with deficiency d
+func (c RGB) SimulateCVD(d Deficiency) RGB {
+	m, ok := cvdMatrices[d]
+	if !ok {
+		return c
+	}
+	lin := [3]float64{srgbToLinear(c.R), srgbToLinear(c.G), srgbToLinear(c.B)}
+	var out [3]float64
+	for i := range out {
+		v := m[i][0]*lin[0] + m[i][1]*lin[1] + m[i][2]*lin[2]
+		out[i] = linearToSrgb(math.Max(0, math.Min(1, v)))
+	}
+	return RGB{R: out[0], G: out[1], B: out[2]}
+}
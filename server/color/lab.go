@@ -0,0 +1,129 @@
+package color
+
+import "math"
+
+// D65 white point used by sRGB
+const whiteX = 0.95047
+const whiteY = 1.0
+const whiteZ = 1.08883
+
+// Lab - CIELAB (D65), L is 0..100
+type Lab struct {
+	L, A, B float64
+}
+
+const labEpsilon = 6.0 / 29.0
+
+func labF(t float64) float64 {
+	if t > labEpsilon*labEpsilon*labEpsilon {
+		return math.Cbrt(t)
+	}
+	return t/(3*labEpsilon*labEpsilon) + 4.0/29.0
+}
+
+func labFInv(t float64) float64 {
+	if t > labEpsilon {
+		return t * t * t
+	}
+	return 3 * labEpsilon * labEpsilon * (t - 4.0/29.0)
+}
+
+// ToLab - convert to CIELAB
+func (c RGB) ToLab() Lab {
+	r, g, b := srgbToLinear(c.R), srgbToLinear(c.G), srgbToLinear(c.B)
+	x := labF((0.4124564*r + 0.3575761*g + 0.1804375*b) / whiteX)
+	y := labF((0.2126729*r + 0.7151522*g + 0.0721750*b) / whiteY)
+	z := labF((0.0193339*r + 0.1191920*g + 0.9503041*b) / whiteZ)
+	return Lab{L: 116*y - 16, A: 500 * (x - y), B: 200 * (y - z)}
+}
+
+// ToRGB - convert CIELAB to RGB, clipping colors outside sRGB
+func (lab Lab) ToRGB() RGB {
+	y := (lab.L + 16) / 116
+	x := whiteX * labFInv(y+lab.A/500)
+	z := whiteZ * labFInv(y-lab.B/200)
+	y = whiteY * labFInv(y)
+	clip := func(c float64) float64 {
+		return linearToSrgb(math.Max(0, math.Min(1, c)))
+	}
+	return RGB{
+		R: clip(3.2404542*x - 1.5371385*y - 0.4985314*z),
+		G: clip(-0.9692660*x + 1.8760108*y + 0.0415560*z),
+		B: clip(0.0556434*x - 0.2040259*y + 1.0572252*z),
+	}
+}
+
+// labHue - hue angle in degrees 0..360
+func labHue(b float64, a float64) float64 {
+	if a == 0 && b == 0 {
+		return 0
+	}
+	h := math.Atan2(b, a) * 180 / math.Pi
+	if h < 0 {
+		h += 360
+	}
+	return h
+}
+
+func deg2rad(d float64) float64 {
+	return d * math.Pi / 180
+}
+
+// DeltaE2000 - CIEDE2000 color difference, around 1 is a just noticeable difference
+func (lab Lab) DeltaE2000(other Lab) float64 {
+	pow25To7 := math.Pow(25, 7)
+	c1 := math.Hypot(lab.A, lab.B)
+	c2 := math.Hypot(other.A, other.B)
+	cBar7 := math.Pow((c1+c2)/2, 7)
+	g := 0.5 * (1 - math.Sqrt(cBar7/(cBar7+pow25To7)))
+	a1p := (1 + g) * lab.A
+	a2p := (1 + g) * other.A
+	c1p := math.Hypot(a1p, lab.B)
+	c2p := math.Hypot(a2p, other.B)
+	h1p := labHue(lab.B, a1p)
+	h2p := labHue(other.B, a2p)
+
+	dLp := other.L - lab.L
+	dCp := c2p - c1p
+	dhp := 0.0
+	if c1p*c2p != 0 {
+		dhp = h2p - h1p
+		if dhp > 180 {
+			dhp -= 360
+		} else if dhp < -180 {
+			dhp += 360
+		}
+	}
+	dHp := 2 * math.Sqrt(c1p*c2p) * math.Sin(deg2rad(dhp/2))
+
+	lBarp := (lab.L + other.L) / 2
+	cBarp := (c1p + c2p) / 2
+	hBarp := h1p + h2p
+	if c1p*c2p != 0 {
+		if math.Abs(h1p-h2p) <= 180 {
+			hBarp = (h1p + h2p) / 2
+		} else if h1p+h2p < 360 {
+			hBarp = (h1p + h2p + 360) / 2
+		} else {
+			hBarp = (h1p + h2p - 360) / 2
+		}
+	}
+
+	t := 1 - 0.17*math.Cos(deg2rad(hBarp-30)) + 0.24*math.Cos(deg2rad(2*hBarp)) +
+		0.32*math.Cos(deg2rad(3*hBarp+6)) - 0.20*math.Cos(deg2rad(4*hBarp-63))
+	dTheta := 30 * math.Exp(-((hBarp-275)/25)*((hBarp-275)/25))
+	cBarp7 := math.Pow(cBarp, 7)
+	rc := 2 * math.Sqrt(cBarp7/(cBarp7+pow25To7))
+	lBarp50 := (lBarp - 50) * (lBarp - 50)
+	sl := 1 + 0.015*lBarp50/math.Sqrt(20+lBarp50)
+	sc := 1 + 0.045*cBarp
+	sh := 1 + 0.015*cBarp*t
+	rt := -math.Sin(deg2rad(2*dTheta)) * rc
+
+	return math.Sqrt((dLp/sl)*(dLp/sl) + (dCp/sc)*(dCp/sc) + (dHp/sh)*(dHp/sh) + rt*(dCp/sc)*(dHp/sh))
+}
+
+// DeltaE2000 - CIEDE2000 difference between two RGB colors
+func (c RGB) DeltaE2000(other RGB) float64 {
+	return c.ToLab().DeltaE2000(other.ToLab())
+}
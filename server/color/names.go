@@ -0,0 +1,62 @@
+package color
+
+// Hue ranges in degrees, each name covers hues up to its bound
+var hueNames = []struct {
+	upTo float64
+	name string
+}{
+	{15, "red"},
+	{40, "orange"},
+	{65, "yellow"},
+	{155, "green"},
+	{185, "teal"},
+	{200, "cyan"},
+	{250, "blue"},
+	{290, "purple"},
+	{330, "magenta"},
+	{345, "pink"},
+	{360, "red"},
+}
+
+// Name - plain english name of the color for descriptions, like "dark teal" or "light gray"
+func (c RGB) Name() string {
+	hsb := c.ToHSB()
+	// Grays
+	if hsb.B < 0.15 {
+		return "black"
+	}
+	if hsb.S < 0.15 {
+		switch {
+		case hsb.B > 0.9:
+			return "white"
+		case hsb.B > 0.7:
+			return "light gray"
+		case hsb.B < 0.4:
+			return "dark gray"
+		}
+		return "gray"
+	}
+
+	name := "red"
+	for _, hn := range hueNames {
+		if hsb.H < hn.upTo {
+			name = hn.name
+			break
+		}
+	}
+	// Dark oranges and yellows read as brown, pale reds as pink
+	if (name == "orange" || name == "yellow") && hsb.B < 0.6 {
+		return "brown"
+	}
+	if name == "red" && hsb.S < 0.55 && hsb.B > 0.75 {
+		return "pink"
+	}
+
+	switch {
+	case hsb.B < 0.45:
+		return "dark " + name
+	case hsb.S < 0.5 && hsb.B > 0.8:
+		return "light " + name
+	}
+	return name
+}
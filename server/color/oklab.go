@@ -0,0 +1,106 @@
+package color
+
+import "math"
+
+// OKLab - perceptual color space where euclidean distance tracks how different colors look, L is 0..1
+type OKLab struct {
+	L, A, B float64
+}
+
+// OKLCH - OKLab in polar form, chroma C is 0 for grays and hue H is 0..360
+type OKLCH struct {
+	L, C, H float64
+}
+
+// srgbToLinear - undo the sRGB transfer curve of a 0..255 component
+func srgbToLinear(c float64) float64 {
+	c /= 255
+	if c <= 0.04045 {
+		return c / 12.92
+	}
+	return math.Pow((c+0.055)/1.055, 2.4)
+}
+
+// linearToSrgb - apply the sRGB transfer curve, returning 0..255
+func linearToSrgb(c float64) float64 {
+	if c <= 0.0031308 {
+		return 255 * 12.92 * c
+	}
+	return 255 * (1.055*math.Pow(c, 1/2.4) - 0.055)
+}
+
+// ToOKLab - convert to OKLab
+func (c RGB) ToOKLab() OKLab {
+	r, g, b := srgbToLinear(c.R), srgbToLinear(c.G), srgbToLinear(c.B)
+	l := math.Cbrt(0.4122214708*r + 0.5363325363*g + 0.0514459929*b)
+	m := math.Cbrt(0.2119034982*r + 0.6806995451*g + 0.1073969566*b)
+	s := math.Cbrt(0.0883024619*r + 0.2817188376*g + 0.6299787005*b)
+	return OKLab{
+		L: 0.2104542553*l + 0.7936177850*m - 0.0040720468*s,
+		A: 1.9779984951*l - 2.4285922050*m + 0.4505937099*s,
+		B: 0.0259040371*l + 0.7827717662*m - 0.8086757660*s,
+	}
+}
+
+// ToOKLCH - convert to OKLCH
+func (c RGB) ToOKLCH() OKLCH {
+	return c.ToOKLab().ToOKLCH()
+}
+
+// linearRGB - linear sRGB components, outside 0..1 when the color is out of gamut
+func (lab OKLab) linearRGB() (float64, float64, float64) {
+	l := lab.L + 0.3963377774*lab.A + 0.2158037573*lab.B
+	m := lab.L - 0.1055613458*lab.A - 0.0638541728*lab.B
+	s := lab.L - 0.0894841775*lab.A - 1.2914855480*lab.B
+	l, m, s = l*l*l, m*m*m, s*s*s
+	return 4.0767416621*l - 3.3077115913*m + 0.2309699292*s,
+		-1.2684380046*l + 2.6097574011*m - 0.3413193965*s,
+		-0.0041960863*l - 0.7034186147*m + 1.7076147010*s
+}
+
+// InGamut - whether the color can be shown in sRGB without clipping
+func (lab OKLab) InGamut() bool {
+	const eps = 1e-6
+	r, g, b := lab.linearRGB()
+	return r >= -eps && r <= 1+eps && g >= -eps && g <= 1+eps && b >= -eps && b <= 1+eps
+}
+
+// ToRGB - convert OKLab to RGB, clipping colors outside sRGB
+func (lab OKLab) ToRGB() RGB {
+	r, g, b := lab.linearRGB()
+	return RGB{
+		R: linearToSrgb(math.Max(0, math.Min(1, r))),
+		G: linearToSrgb(math.Max(0, math.Min(1, g))),
+		B: linearToSrgb(math.Max(0, math.Min(1, b))),
+	}
+}
+
+// ToOKLCH - convert OKLab to OKLCH
+func (lab OKLab) ToOKLCH() OKLCH {
+	h := math.Atan2(lab.B, lab.A) * 180 / math.Pi
+	if h < 0 {
+		h += 360
+	}
+	return OKLCH{L: lab.L, C: math.Hypot(lab.A, lab.B), H: h}
+}
+
+// Distance - euclidean distance in OKLab, around 0.02 is a just noticeable difference
+func (lab OKLab) Distance(other OKLab) float64 {
+	return math.Sqrt((lab.L-other.L)*(lab.L-other.L) + (lab.A-other.A)*(lab.A-other.A) + (lab.B-other.B)*(lab.B-other.B))
+}
+
+// ToOKLab - convert OKLCH to OKLab
+func (lch OKLCH) ToOKLab() OKLab {
+	h := lch.H * math.Pi / 180
+	return OKLab{L: lch.L, A: lch.C * math.Cos(h), B: lch.C * math.Sin(h)}
+}
+
+// ToRGB - convert OKLCH to RGB, clipping colors outside sRGB
+func (lch OKLCH) ToRGB() RGB {
+	return lch.ToOKLab().ToRGB()
+}
+
+// InGamut - whether the color can be shown in sRGB without clipping
+func (lch OKLCH) InGamut() bool {
+	return lch.ToOKLab().InGamut()
+}
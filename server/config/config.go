@@ -0,0 +1,409 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/paw-digital/Pawnimals/server/spc"
+	"gopkg.in/yaml.v2"
+)
+
+// Config - every server setting, loaded from defaults, then a YAML file, then the environment, then flags
+type Config struct {
+	Seed       string    `yaml:"seed" json:"seed"`
+	AdminToken string    `yaml:"admin_token" json:"admin_token"`
+	Scheme     int       `yaml:"scheme" json:"scheme"`     // Color generation scheme, changing it recolors every natricon
+	CVDSafe    bool      `yaml:"cvd_safe" json:"cvd_safe"` // Keep body and hair apart for color-blind viewers, recolors some natricons
+	Server     Server    `yaml:"server" json:"server"`
+	Redis      Redis     `yaml:"redis" json:"redis"`
+	Nano       Nano      `yaml:"nano" json:"nano"`
+	Donations  Donations `yaml:"donations" json:"donations"`
+	Raster     Raster    `yaml:"raster" json:"raster"`
+	Cron       Cron      `yaml:"cron" json:"cron"`
+	CORS       CORS      `yaml:"cors" json:"cors"`
+	RateLimit  RateLimit `yaml:"rate_limit" json:"rate_limit"`
+}
+
+type Server struct {
+	Host           string   `yaml:"host" json:"host"`
+	Port           int      `yaml:"port" json:"port"`
+	TrustedProxies []string `yaml:"trusted_proxies" json:"trusted_proxies"` // IPs or CIDRs of load balancers allowed to set X-Forwarded-For
+}
+
+type Redis struct {
+	Host string `yaml:"host" json:"host"`
+	Port int    `yaml:"port" json:"port"`
+	DB   int    `yaml:"db" json:"db"`
+}
+
+type Nano struct {
+	RPCURL          string `yaml:"rpc_url" json:"rpc_url"`                   // Optional URL to use for nano RPC Client
+	WSURL           string `yaml:"ws_url" json:"ws_url"`                     // Nano WS Url to use for tracking donation account
+	DonationAccount string `yaml:"donation_account" json:"donation_account"` // Account to listen for donations on
+	WalletID        string `yaml:"wallet_id" json:"wallet_id"`               // Wallet refunds are sent from
+}
+
+type Donations struct {
+	ThresholdNano  float64 `yaml:"threshold_nano" json:"threshold_nano"`   // Donations at or above this award donor status for 30 days
+	ReRandomAmount string  `yaml:"rerandom_amount" json:"rerandom_amount"` // Raw amount that triggers re-randomization
+}
+
+type Raster struct {
+	DefaultSize int `yaml:"default_size" json:"default_size"` // Default size of PNG/WEBP images
+	MinSize     int `yaml:"min_size" json:"min_size"`         // Minimum size of PNG/WEBP converted output
+	MaxSize     int `yaml:"max_size" json:"max_size"`         // Maximum size of PNG/WEBP converted output
+	CacheMB     int `yaml:"cache_mb" json:"cache_mb"`         // Megabytes of converted images kept in memory, 0 turns the cache off
+}
+
+// Cron - job intervals in minutes
+type Cron struct {
+	CheckMissedCallbacks  uint64 `yaml:"check_missed_callbacks" json:"check_missed_callbacks"`
+	UpdatePrincipalWeight uint64 `yaml:"update_principal_weight" json:"update_principal_weight"`
+	UpdatePrincipalReps   uint64 `yaml:"update_principal_reps" json:"update_principal_reps"`
+}
+
+// CORS - origins allowed to make credentialed or admin requests, public API routes are open to everyone
+type CORS struct {
+	AllowedOrigins []string `yaml:"allowed_origins" json:"allowed_origins"` // Exact origins or wildcards like https://*.natricon.com
+	AdminOrigins   []string `yaml:"admin_origins" json:"admin_origins"`     // Origins allowed to call admin routes from a browser
+}
+
+// RateLimit - token buckets for the public API, per client IP or per API key
+type RateLimit struct {
+	Enabled    bool     `yaml:"enabled" json:"enabled"`
+	Rate       float64  `yaml:"rate" json:"rate"`               // Tokens per second for clients without a key
+	Burst      int      `yaml:"burst" json:"burst"`             // Bucket size for clients without a key
+	RasterCost int      `yaml:"raster_cost" json:"raster_cost"` // Tokens a PNG/WEBP request costs, everything else costs 1
+	APIKeys    []APIKey `yaml:"api_keys" json:"api_keys"`
+}
+
+// APIKey - partner key, requests with it are attributed to Service in stats and get their own quota
+type APIKey struct {
+	Key     string  `yaml:"key" json:"key"`
+	Service string  `yaml:"service" json:"service"`
+	Rate    float64 `yaml:"rate" json:"rate"`
+	Burst   int     `yaml:"burst" json:"burst"`
+}
+
+// Color schemes the server can generate, kept in step with image.Schemes so config doesn't depend on rendering
+const minScheme = 1
+const maxScheme = 5
+const defaultScheme = 1
+
+// Defaults - config used for anything not set elsewhere
+func Defaults() Config {
+	return Config{
+		Seed:   "0123456789",
+		Scheme: defaultScheme,
+		Server: Server{
+			Host: "127.0.0.1",
+			Port: 8080,
+		},
+		Redis: Redis{
+			Host: "localhost",
+			Port: 6379,
+			DB:   0,
+		},
+		Donations: Donations{
+			ThresholdNano:  2000.0,
+			ReRandomAmount: "1000000000000000000000000000",
+		},
+		Raster: Raster{
+			DefaultSize: 128,
+			MinSize:     16,
+			MaxSize:     1000,
+			CacheMB:     64,
+		},
+		Cron: Cron{
+			CheckMissedCallbacks:  30,
+			UpdatePrincipalWeight: 30,
+			UpdatePrincipalReps:   30,
+		},
+		CORS: CORS{
+			AllowedOrigins: []string{"https://natricon.com", "https://*.natricon.com"},
+		},
+		RateLimit: RateLimit{
+			Enabled:    true,
+			Rate:       5,
+			Burst:      60,
+			RasterCost: 5,
+		},
+	}
+}
+
+// binding - ties a config field to its environment variable and flag
+type binding struct {
+	env   string
+	flag  string
+	usage string
+	field func(c *Config) interface{}
+}
+
+var bindings = []binding{
+	{"NATRICON_SEED", "seed", "Seed used to hash addresses", func(c *Config) interface{} { return &c.Seed }},
+	{"ADMIN_TOKEN", "admin-token", "Bearer token for admin routes, admin routes are disabled if empty", func(c *Config) interface{} { return &c.AdminToken }},
+	{"COLOR_SCHEME", "scheme", "Color generation scheme version", func(c *Config) interface{} { return &c.Scheme }},
+	{"COLOR_CVD_SAFE", "cvd-safe", "Keep body and hair colors distinguishable under color vision deficiencies", func(c *Config) interface{} { return &c.CVDSafe }},
+	{"SERVER_HOST", "host", "Host to listen on", func(c *Config) interface{} { return &c.Server.Host }},
+	{"SERVER_PORT", "port", "Port to listen on", func(c *Config) interface{} { return &c.Server.Port }},
+	{"SERVER_TRUSTED_PROXIES", "trusted-proxies", "Comma separated IPs or CIDRs of load balancers allowed to set X-Forwarded-For", func(c *Config) interface{} { return &c.Server.TrustedProxies }},
+	{"REDIS_HOST", "redis-host", "Redis host", func(c *Config) interface{} { return &c.Redis.Host }},
+	{"REDIS_PORT", "redis-port", "Redis port", func(c *Config) interface{} { return &c.Redis.Port }},
+	{"REDIS_DB", "redis-db", "Redis database", func(c *Config) interface{} { return &c.Redis.DB }},
+	{"RPC_URL", "rpc-url", "Optional URL to use for nano RPC Client", func(c *Config) interface{} { return &c.Nano.RPCURL }},
+	{"NANO_WS_URL", "nano-ws-url", "Nano WS Url to use for tracking donation account", func(c *Config) interface{} { return &c.Nano.WSURL }},
+	{"DONATION_ACCOUNT", "donation-account", "Account to track donations on", func(c *Config) interface{} { return &c.Nano.DonationAccount }},
+	{"WALLET_ID", "wallet-id", "Wallet to send refunds from", func(c *Config) interface{} { return &c.Nano.WalletID }},
+	{"DONATION_THRESHOLD_NANO", "donation-threshold", "Donation amount awarding donor status for 30 days", func(c *Config) interface{} { return &c.Donations.ThresholdNano }},
+	{"DONATION_RERANDOM_AMOUNT", "donation-rerandom-amount", "Raw donation amount that re-randomizes a natricon", func(c *Config) interface{} { return &c.Donations.ReRandomAmount }},
+	{"RASTER_DEFAULT_SIZE", "raster-default-size", "Default size of PNG/WEBP images", func(c *Config) interface{} { return &c.Raster.DefaultSize }},
+	{"RASTER_MIN_SIZE", "raster-min-size", "Minimum size of PNG/WEBP images", func(c *Config) interface{} { return &c.Raster.MinSize }},
+	{"RASTER_MAX_SIZE", "raster-max-size", "Maximum size of PNG/WEBP images", func(c *Config) interface{} { return &c.Raster.MaxSize }},
+	{"RASTER_CACHE_MB", "raster-cache-mb", "Megabytes of converted images kept in memory, 0 turns the cache off", func(c *Config) interface{} { return &c.Raster.CacheMB }},
+	{"RATE_LIMIT_ENABLED", "rate-limit", "Rate limit the public API", func(c *Config) interface{} { return &c.RateLimit.Enabled }},
+	{"RATE_LIMIT_RATE", "rate-limit-rate", "Tokens per second for clients without an API key", func(c *Config) interface{} { return &c.RateLimit.Rate }},
+	{"RATE_LIMIT_BURST", "rate-limit-burst", "Bucket size for clients without an API key", func(c *Config) interface{} { return &c.RateLimit.Burst }},
+	{"RATE_LIMIT_RASTER_COST", "rate-limit-raster-cost", "Tokens a PNG/WEBP request costs", func(c *Config) interface{} { return &c.RateLimit.RasterCost }},
+	{"CRON_CHECK_MISSED_CALLBACKS", "cron-check-missed-callbacks", "Minutes between missed donation checks", func(c *Config) interface{} { return &c.Cron.CheckMissedCallbacks }},
+	{"CRON_UPDATE_PRINCIPAL_WEIGHT", "cron-update-principal-weight", "Minutes between principal rep requirement updates", func(c *Config) interface{} { return &c.Cron.UpdatePrincipalWeight }},
+	{"CRON_UPDATE_PRINCIPAL_REPS", "cron-update-principal-reps", "Minutes between principal rep list updates", func(c *Config) interface{} { return &c.Cron.UpdatePrincipalReps }},
+	{"CORS_ALLOWED_ORIGINS", "cors-allowed-origins", "Comma separated origins allowed to make credentialed requests", func(c *Config) interface{} { return &c.CORS.AllowedOrigins }},
+	{"CORS_ADMIN_ORIGINS", "cors-admin-origins", "Comma separated origins allowed to call admin routes", func(c *Config) interface{} { return &c.CORS.AdminOrigins }},
+}
+
+// setField - parse raw into the field pointed to by target
+func setField(target interface{}, raw string) error {
+	var err error
+	switch t := target.(type) {
+	case *string:
+		*t = raw
+	case *int:
+		*t, err = strconv.Atoi(raw)
+	case *uint64:
+		*t, err = strconv.ParseUint(raw, 10, 64)
+	case *float64:
+		*t, err = strconv.ParseFloat(raw, 64)
+	case *bool:
+		*t, err = strconv.ParseBool(raw)
+	case *[]string:
+		*t = []string{}
+		for _, v := range strings.Split(raw, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				*t = append(*t, v)
+			}
+		}
+	default:
+		err = fmt.Errorf("unsupported config type %T", target)
+	}
+	return err
+}
+
+// Flags - command line overrides, only flags that were actually passed are applied
+type Flags struct {
+	File string
+	set  map[string]string
+}
+
+type flagOverride struct {
+	name  string
+	flags *Flags
+}
+
+func (f flagOverride) String() string {
+	return ""
+}
+
+func (f flagOverride) Set(value string) error {
+	f.flags.set[f.name] = value
+	return nil
+}
+
+// RegisterFlags - add -config and a flag for every setting to fs
+func RegisterFlags(fs *flag.FlagSet) *Flags {
+	f := &Flags{set: map[string]string{}}
+	fs.StringVar(&f.File, "config", "", "Path to a YAML config file")
+	for _, b := range bindings {
+		fs.Var(flagOverride{name: b.flag, flags: f}, b.flag, fmt.Sprintf("%s (env %s)", b.usage, b.env))
+	}
+	return f
+}
+
+// Load - build the config and validate it
+func Load(flags *Flags) (*Config, error) {
+	cfg := Defaults()
+	if flags != nil && flags.File != "" {
+		raw, err := ioutil.ReadFile(flags.File)
+		if err != nil {
+			return nil, err
+		}
+		if err = yaml.UnmarshalStrict(raw, &cfg); err != nil {
+			return nil, fmt.Errorf("invalid config file %s: %s", flags.File, err)
+		}
+	}
+	for _, b := range bindings {
+		raw, ok := os.LookupEnv(b.env)
+		if !ok || raw == "" {
+			continue
+		}
+		if err := setField(b.field(&cfg), raw); err != nil {
+			return nil, fmt.Errorf("invalid %s: %s", b.env, err)
+		}
+	}
+	if flags != nil {
+		for _, b := range bindings {
+			raw, ok := flags.set[b.flag]
+			if !ok {
+				continue
+			}
+			if err := setField(b.field(&cfg), raw); err != nil {
+				return nil, fmt.Errorf("invalid -%s: %s", b.flag, err)
+			}
+		}
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// Validate - returns the first setting that doesn't make sense
+func (c Config) Validate() error {
+	if c.Seed == "" {
+		return errors.New("seed can't be empty")
+	}
+	if c.Scheme < minScheme || c.Scheme > maxScheme {
+		return fmt.Errorf("scheme %d must be between %d and %d", c.Scheme, minScheme, maxScheme)
+	}
+	if c.Server.Port < 1 || c.Server.Port > 65535 {
+		return fmt.Errorf("server port %d out of range", c.Server.Port)
+	}
+	for _, proxy := range c.Server.TrustedProxies {
+		if err := validateProxy(proxy); err != nil {
+			return err
+		}
+	}
+	if c.Redis.Port < 1 || c.Redis.Port > 65535 {
+		return fmt.Errorf("redis port %d out of range", c.Redis.Port)
+	}
+	if c.Redis.DB < 0 {
+		return fmt.Errorf("redis db %d can't be negative", c.Redis.DB)
+	}
+	if c.Donations.ThresholdNano <= 0 {
+		return errors.New("donation threshold must be positive")
+	}
+	if amount, ok := new(big.Int).SetString(c.Donations.ReRandomAmount, 10); !ok || amount.Sign() <= 0 {
+		return fmt.Errorf("donation re-random amount %s must be a positive raw amount", c.Donations.ReRandomAmount)
+	}
+	if c.Raster.MinSize < 1 || c.Raster.MinSize > c.Raster.MaxSize {
+		return fmt.Errorf("raster sizes must satisfy 1 <= min (%d) <= max (%d)", c.Raster.MinSize, c.Raster.MaxSize)
+	}
+	if c.Raster.DefaultSize < c.Raster.MinSize || c.Raster.DefaultSize > c.Raster.MaxSize {
+		return fmt.Errorf("default raster size %d must be between %d and %d", c.Raster.DefaultSize, c.Raster.MinSize, c.Raster.MaxSize)
+	}
+	if c.Raster.CacheMB < 0 {
+		return fmt.Errorf("raster cache of %d MB can't be negative", c.Raster.CacheMB)
+	}
+	if c.Cron.CheckMissedCallbacks == 0 || c.Cron.UpdatePrincipalWeight == 0 || c.Cron.UpdatePrincipalReps == 0 {
+		return errors.New("cron intervals must be at least 1 minute")
+	}
+	for _, origins := range [][]string{c.CORS.AllowedOrigins, c.CORS.AdminOrigins} {
+		for _, origin := range origins {
+			if err := validateOrigin(origin); err != nil {
+				return err
+			}
+		}
+	}
+	if err := c.RateLimit.validate(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r RateLimit) validate() error {
+	if !r.Enabled {
+		return nil
+	}
+	if r.Rate <= 0 || r.Burst < 1 {
+		return errors.New("rate limit rate and burst must be positive")
+	}
+	if r.RasterCost < 1 || r.RasterCost > r.Burst {
+		return fmt.Errorf("raster cost %d must be between 1 and the burst %d", r.RasterCost, r.Burst)
+	}
+	seen := map[string]bool{}
+	for _, k := range r.APIKeys {
+		if k.Key == "" || seen[k.Key] {
+			return fmt.Errorf("API key for %s is empty or duplicated", k.Service)
+		}
+		seen[k.Key] = true
+		if !knownService(k.Service) {
+			return fmt.Errorf("API key service %s is not registered for stats", k.Service)
+		}
+		if k.Rate <= 0 || k.Burst < r.RasterCost {
+			return fmt.Errorf("API key for %s needs a positive rate and a burst of at least %d", k.Service, r.RasterCost)
+		}
+	}
+	return nil
+}
+
+func knownService(svc string) bool {
+	for _, s := range spc.SvcList {
+		if string(s) == svc {
+			return true
+		}
+	}
+	return false
+}
+
+// validateProxy - an IP or a CIDR
+func validateProxy(proxy string) error {
+	if net.ParseIP(proxy) != nil {
+		return nil
+	}
+	if _, _, err := net.ParseCIDR(proxy); err != nil {
+		return fmt.Errorf("invalid trusted proxy %s, expected an IP or a CIDR", proxy)
+	}
+	return nil
+}
+
+// validateOrigin - scheme://host[:port] with at most one * in the host, a bare * is not allowed
+func validateOrigin(origin string) error {
+	u, err := url.Parse(origin)
+	if err != nil || u.Scheme == "" || u.Host == "" || u.Path != "" || u.RawQuery != "" {
+		return fmt.Errorf("invalid CORS origin %s, expected scheme://host", origin)
+	}
+	if strings.Count(u.Host, "*") > 1 || (strings.Contains(u.Host, "*") && !strings.HasPrefix(u.Host, "*.")) {
+		return fmt.Errorf("invalid CORS origin %s, only a leading *. subdomain wildcard is supported", origin)
+	}
+	return nil
+}
+
+// Placeholder for secrets in Redacted
+const redacted = "[redacted]"
+
+// Redacted - copy of the config that is safe to show, secrets are masked
+func (c Config) Redacted() Config {
+	if c.Seed != "" {
+		c.Seed = redacted
+	}
+	if c.AdminToken != "" {
+		c.AdminToken = redacted
+	}
+	if c.Nano.WalletID != "" {
+		c.Nano.WalletID = redacted
+	}
+	keys := make([]APIKey, len(c.RateLimit.APIKeys))
+	for i, k := range c.RateLimit.APIKeys {
+		k.Key = redacted
+		keys[i] = k
+	}
+	c.RateLimit.APIKeys = keys
+	return c
+}
@@ -0,0 +1,136 @@
+package config
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/paw-digital/Pawnimals/server/image"
+)
+
+func TestLoadPrecedence(t *testing.T) {
+	file, err := ioutil.TempFile("", "config*.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString("server:\n  host: 0.0.0.0\n  port: 9000\nredis:\n  host: redis\n")
+	file.Close()
+
+	os.Setenv("SERVER_PORT", "9100")
+	defer os.Unsetenv("SERVER_PORT")
+	os.Setenv("REDIS_DB", "2")
+	defer os.Unsetenv("REDIS_DB")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags := RegisterFlags(fs)
+	if err := fs.Parse([]string{"-config", file.Name(), "-redis-db", "3"}); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(flags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Server.Host != "0.0.0.0" {
+		t.Errorf("Expected %s but got %s", "0.0.0.0", cfg.Server.Host)
+	}
+	if cfg.Server.Port != 9100 {
+		t.Errorf("Expected %d but got %d", 9100, cfg.Server.Port)
+	}
+	if cfg.Redis.Host != "redis" {
+		t.Errorf("Expected %s but got %s", "redis", cfg.Redis.Host)
+	}
+	if cfg.Redis.DB != 3 {
+		t.Errorf("Expected %d but got %d", 3, cfg.Redis.DB)
+	}
+	if cfg.Raster.DefaultSize != 128 {
+		t.Errorf("Expected %d but got %d", 128, cfg.Raster.DefaultSize)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cfg := Defaults()
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected defaults to be valid but got %s", err)
+	}
+	cfg.Raster.DefaultSize = 8
+	if err := cfg.Validate(); err == nil {
+		t.Errorf("Expected error for default size below min")
+	}
+	cfg = Defaults()
+	cfg.Scheme = 9
+	if err := cfg.Validate(); err == nil {
+		t.Errorf("Expected error for unknown scheme")
+	}
+	cfg = Defaults()
+	cfg.Donations.ReRandomAmount = "abc"
+	if err := cfg.Validate(); err == nil {
+		t.Errorf("Expected error for invalid re-random amount")
+	}
+	for _, origin := range []string{"*", "natricon.com", "https://natricon.com/path", "https://a.*.natricon.com"} {
+		cfg = Defaults()
+		cfg.CORS.AllowedOrigins = []string{origin}
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("Expected error for origin %s", origin)
+		}
+	}
+	cfg = Defaults()
+	cfg.Server.TrustedProxies = []string{"10.0.0.0/8", "192.168.1.1", "10.0.0"}
+	if err := cfg.Validate(); err == nil {
+		t.Errorf("Expected error for trusted proxy 10.0.0")
+	}
+}
+
+func TestValidateAPIKeys(t *testing.T) {
+	cfg := Defaults()
+	cfg.RateLimit.APIKeys = []APIKey{{Key: "abc", Service: "natrium", Rate: 50, Burst: 500}}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected valid API key but got %s", err)
+	}
+	cfg.RateLimit.APIKeys = append(cfg.RateLimit.APIKeys, APIKey{Key: "abc", Service: "nanolooker", Rate: 50, Burst: 500})
+	if err := cfg.Validate(); err == nil {
+		t.Errorf("Expected error for duplicate API key")
+	}
+	cfg.RateLimit.APIKeys = []APIKey{{Key: "abc", Service: "unknown", Rate: 50, Burst: 500}}
+	if err := cfg.Validate(); err == nil {
+		t.Errorf("Expected error for unregistered service")
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	cfg := Defaults()
+	cfg.Nano.WalletID = "ABCDEF"
+	cfg.AdminToken = "secret"
+	cfg.RateLimit.APIKeys = []APIKey{{Key: "abc", Service: "natrium"}}
+	r := cfg.Redacted()
+	if r.Seed != redacted || r.Nano.WalletID != redacted || r.AdminToken != redacted {
+		t.Errorf("Expected secrets to be redacted but got %s %s %s", r.Seed, r.Nano.WalletID, r.AdminToken)
+	}
+	if r.RateLimit.APIKeys[0].Key != redacted || r.RateLimit.APIKeys[0].Service != "natrium" {
+		t.Errorf("Expected API key to be redacted but got %s", r.RateLimit.APIKeys[0].Key)
+	}
+	if cfg.RateLimit.APIKeys[0].Key != "abc" {
+		t.Errorf("Expected %s but got %s", "abc", cfg.RateLimit.APIKeys[0].Key)
+	}
+	if cfg.Nano.WalletID != "ABCDEF" {
+		t.Errorf("Expected %s but got %s", "ABCDEF", cfg.Nano.WalletID)
+	}
+}
+
+func TestSchemesInStep(t *testing.T) {
+	cfg := Defaults()
+	if cfg.Scheme != int(image.DefaultScheme) {
+		t.Errorf("Expected the default scheme %d but got %d", image.DefaultScheme, cfg.Scheme)
+	}
+	for _, s := range image.Schemes {
+		cfg.Scheme = int(s)
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("Expected scheme %d to be valid but got %s", s, err)
+		}
+	}
+	cfg.Scheme = int(image.Schemes[len(image.Schemes)-1]) + 1
+	if err := cfg.Validate(); err == nil {
+		t.Errorf("Expected scheme %d to be unknown", cfg.Scheme)
+	}
+}
@@ -0,0 +1,29 @@
+package controller
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/paw-digital/Pawnimals/server/config"
+	"github.com/gin-gonic/gin"
+)
+
+type AdminController struct {
+	Config *config.Config
+}
+
+// RequireToken - reject requests without the admin bearer token
+func (ac AdminController) RequireToken(c *gin.Context) {
+	token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if ac.Config.AdminToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(ac.Config.AdminToken)) != 1 {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+	c.Next()
+}
+
+// GetConfig - dump the running config with secrets masked
+func (ac AdminController) GetConfig(c *gin.Context) {
+	c.JSON(http.StatusOK, ac.Config.Redacted())
+}
@@ -0,0 +1,70 @@
+package controller
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/paw-digital/Pawnimals/server/image"
+	"github.com/paw-digital/Pawnimals/server/spc"
+	"github.com/paw-digital/Pawnimals/server/utils"
+)
+
+// How many of the most alike pairs SearchCollisions prints
+const collisionReportSize = 10
+
+type collisionPair struct {
+	a, b string
+	sim  image.Similarity
+}
+
+// SearchCollisions - compare count random accounts pairwise and report how many natricons look alike, for the -collisions command
+func SearchCollisions(w io.Writer, seed string, gen image.Generation, count int) {
+	// Only icons with the same face can look alike, so only compare within a face
+	byFace := map[string][]string{}
+	accessories := map[string]image.Accessories{}
+	for i := 0; i < count; i++ {
+		address := utils.GenerateAddress()
+		acc, err := image.GetAccessoriesWith(utils.PKSha256(utils.AddressToPub(address), seed), gen, spc.BTNone, false, nil)
+		if err != nil {
+			fmt.Fprintf(w, "Skipping %s: %s\n", address, err)
+			continue
+		}
+		accessories[address] = acc
+		byFace[acc.FaceAsset.FileName] = append(byFace[acc.FaceAsset.FileName], address)
+	}
+
+	verdicts := map[image.Verdict]int{}
+	var worst []collisionPair
+	compared := 0
+	for _, addresses := range byFace {
+		for i := 0; i < len(addresses); i++ {
+			for j := i + 1; j < len(addresses); j++ {
+				sim := image.Compare(accessories[addresses[i]], accessories[addresses[j]])
+				compared++
+				verdicts[sim.Verdict]++
+				if len(worst) < collisionReportSize || sim.Score > worst[len(worst)-1].sim.Score {
+					worst = append(worst, collisionPair{addresses[i], addresses[j], sim})
+					sort.Slice(worst, func(x, y int) bool { return worst[x].sim.Score > worst[y].sim.Score })
+					if len(worst) > collisionReportSize {
+						worst = worst[:collisionReportSize]
+					}
+				}
+			}
+		}
+	}
+
+	total := len(accessories) * (len(accessories) - 1) / 2
+	fmt.Fprintf(w, "%d accounts, %d pairs, %d with the same face\n", len(accessories), total, compared)
+	for _, v := range []image.Verdict{image.VerdictLookAlike, image.VerdictSimilar} {
+		if verdicts[v] == 0 {
+			fmt.Fprintf(w, "%s: none\n", v)
+			continue
+		}
+		fmt.Fprintf(w, "%s: %d (1 in %.0f pairs)\n", v, verdicts[v], float64(total)/float64(verdicts[v]))
+	}
+	fmt.Fprintf(w, "Most alike:\n")
+	for _, p := range worst {
+		fmt.Fprintf(w, "%.3f %s body %.3f hair %.3f\n  %s\n  %s\n", p.sim.Score, p.sim.Verdict, p.sim.BodyDistance, p.sim.HairDistance, p.a, p.b)
+	}
+}
@@ -0,0 +1,152 @@
+package controller
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/paw-digital/Pawnimals/server/color"
+	"github.com/paw-digital/Pawnimals/server/db"
+	"github.com/paw-digital/Pawnimals/server/magickwand"
+	"github.com/paw-digital/Pawnimals/server/metrics"
+	"github.com/paw-digital/Pawnimals/server/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// PNGs in a favicon pack, the apple touch icon gets a white background since iOS fills transparency with black
+var faviconPNGs = []struct {
+	name   string
+	size   int
+	opaque bool
+}{
+	{"favicon-16x16.png", 16, false},
+	{"favicon-32x32.png", 32, false},
+	{"apple-touch-icon.png", 180, true},
+	{"icon-192.png", 192, false},
+	{"icon-512.png", 512, false},
+}
+
+// FaviconPackConversions - raster conversions a favicon pack takes, every PNG plus each size in the ICO
+var FaviconPackConversions = len(faviconPNGs) + len(magickwand.IcoSizes)
+
+const faviconHead = `<link rel="icon" href="/favicon.ico" sizes="any">
+<link rel="icon" href="/favicon.svg" type="image/svg+xml">
+<link rel="icon" type="image/png" sizes="32x32" href="/favicon-32x32.png">
+<link rel="icon" type="image/png" sizes="16x16" href="/favicon-16x16.png">
+<link rel="apple-touch-icon" href="/apple-touch-icon.png">
+<link rel="manifest" href="/site.webmanifest">
+`
+
+type packFile struct {
+	name string
+	data []byte
+}
+
+type manifestIcon struct {
+	Src   string `json:"src"`
+	Sizes string `json:"sizes"`
+	Type  string `json:"type"`
+}
+
+// GetFaviconPack - zip of every favicon a site needs for an address, honouring the same style options as GetNano
+func (nc NatriconController) GetFaviconPack(c *gin.Context) {
+	address := c.Query("address")
+	nonce, err := strconv.Atoi(c.Query("nonce"))
+	if err != nil {
+		nonce = db.NoNonceApplied
+	}
+	valid := utils.ValidateAddress(address)
+	if !valid {
+		c.String(http.StatusBadRequest, "Invalid address")
+		return
+	}
+	if !magickwand.Supports("ico") {
+		c.String(http.StatusNotImplemented, "ico is not supported by this server")
+		return
+	}
+	style, err := parseStyle(c)
+	if err != nil {
+		c.String(http.StatusBadRequest, "%s", err.Error())
+		return
+	}
+	gen, err := nc.parseGeneration(c)
+	if err != nil {
+		c.String(http.StatusBadRequest, "%s", err.Error())
+		return
+	}
+	start := time.Now()
+	defer metrics.ObserveIconRequest("favicon-pack", 0, start)
+
+	pack, err := nc.faviconPack(nc.resolveAddress(address, nonce, gen), style, address)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Error occured")
+		return
+	}
+	c.Header("Content-Disposition", `attachment; filename="pawnimal-favicons.zip"`)
+	c.Data(200, "application/zip", pack)
+}
+
+// faviconPack - build the zip for GetFaviconPack
+func (nc NatriconController) faviconPack(src iconSource, style iconStyle, address string) ([]byte, error) {
+	svg, err := style.render(src, "svg", 0, address)
+	if err != nil {
+		return nil, err
+	}
+	opaqueStyle := style
+	if opaqueStyle.frame.Background == nil {
+		opaqueStyle.frame.Background = &color.RGB{R: 255.0, G: 255.0, B: 255.0}
+	}
+	ico, err := style.ico(src, address)
+	if err != nil {
+		return nil, err
+	}
+	files := []packFile{{"favicon.svg", svg}, {"favicon.ico", ico}}
+	var icons []manifestIcon
+	for _, png := range faviconPNGs {
+		pngStyle := style
+		if png.opaque {
+			pngStyle = opaqueStyle
+		}
+		source, err := pngStyle.render(src, "png", png.size, address)
+		if err != nil {
+			return nil, err
+		}
+		data, err := rasterize(source, "png", png.size)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, packFile{png.name, data})
+		if png.size >= 192 && !png.opaque {
+			icons = append(icons, manifestIcon{
+				Src:   "/" + png.name,
+				Sizes: fmt.Sprintf("%dx%d", png.size, png.size),
+				Type:  "image/png",
+			})
+		}
+	}
+	manifest, err := json.MarshalIndent(gin.H{"icons": icons}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+	files = append(files, packFile{"site.webmanifest", manifest}, packFile{"head.html", []byte(faviconHead)})
+	for _, f := range files {
+		w, err := zw.Create(f.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(f.data); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
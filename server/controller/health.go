@@ -0,0 +1,79 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/paw-digital/Pawnimals/server/db"
+	"github.com/paw-digital/Pawnimals/server/image"
+	"github.com/paw-digital/Pawnimals/server/magickwand"
+	"github.com/paw-digital/Pawnimals/server/net"
+	"github.com/gin-gonic/gin"
+)
+
+type HealthController struct {
+	WSEnabled bool // Whether the nano websocket is expected to be subscribed
+}
+
+// Healthz - liveness, only checks what a restart could fix
+func (hc HealthController) Healthz(c *gin.Context) {
+	respondChecks(c, map[string]error{
+		"assets":     checkAssets(),
+		"rasterizer": magickwand.CheckFormats(),
+	}, nil)
+}
+
+// Readyz - readiness, also checks external dependencies
+func (hc HealthController) Readyz(c *gin.Context) {
+	checks := map[string]error{
+		"assets":     checkAssets(),
+		"rasterizer": magickwand.CheckFormats(),
+		"redis":      db.GetDB().Ping(),
+	}
+	// Icons are still served without the websocket, only donations are missed,
+	// so it's reported but doesn't take the instance out of rotation
+	degraded := map[string]error{}
+	if hc.WSEnabled {
+		degraded["nano_ws"] = checkWS()
+	}
+	respondChecks(c, checks, degraded)
+}
+
+func checkAssets() error {
+	if image.GetAssets().GetNFaceAssets() == 0 {
+		return errors.New("No face assets loaded")
+	}
+	return nil
+}
+
+func checkWS() error {
+	if !net.WSSubscribed() {
+		return errors.New("Not subscribed to confirmations")
+	}
+	return nil
+}
+
+// respondChecks - 200 if every check passed, 503 otherwise, degraded checks never fail the response
+func respondChecks(c *gin.Context, checks map[string]error, degraded map[string]error) {
+	status := http.StatusOK
+	results := gin.H{}
+	for name, err := range checks {
+		if err != nil {
+			status = http.StatusServiceUnavailable
+			results[name] = err.Error()
+		} else {
+			results[name] = "ok"
+		}
+	}
+	for name, err := range degraded {
+		if err != nil {
+			results[name] = err.Error()
+		} else {
+			results[name] = "ok"
+		}
+	}
+	c.JSON(status, gin.H{
+		"ok":     status == http.StatusOK,
+		"checks": results,
+	})
+}
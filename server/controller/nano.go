@@ -2,29 +2,34 @@ package controller
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
 	"time"
 
+	"github.com/paw-digital/Pawnimals/server/config"
 	"github.com/paw-digital/Pawnimals/server/db"
 	"github.com/paw-digital/Pawnimals/server/image"
+	"github.com/paw-digital/Pawnimals/server/metrics"
 	"github.com/paw-digital/Pawnimals/server/net"
+	"github.com/paw-digital/Pawnimals/server/spc"
 	"github.com/paw-digital/Pawnimals/server/utils"
 	"github.com/bsm/redislock"
 	"github.com/golang/glog"
 	socketio "github.com/googollee/go-socket.io"
 )
 
-// Donations at or above this threshold will award "vip" status for 30 days
-const donationThresholdNano = 2000.0
+// Share of online weight a rep needs to be considered a principal rep
+const principalRepWeightRatio = 0.001
 
-// Donations of this amount will be re-randomized
-//const donationReRandomAmount = "1234567891234567891234567891"
-const donationReRandomAmount = "1000000000000000000000000000"
+// This many highest weighted principal reps get the top node badge
+const topRepCount = 10
 
 type NanoController struct {
 	RPCClient       *net.RPCClient
 	SIOServer       *socketio.Server
 	DonationAccount string
+	WalletID        string
+	Donations       config.Donations
 }
 
 // Handle callback for donation listener
@@ -48,7 +53,7 @@ func (nc NanoController) Callback(confirmationResponse net.ConfirmationResponse)
 			//if len(amount) == 28 && string(amountRune[0:6]) == "123456" {
 			if len(amount) == 28 && string(amountRune[0:6]) == "100000" {
 				amountBig, _ := utils.RawToBigInt(amount)
-				reRandomTrigger, _ := utils.RawToBigInt(donationReRandomAmount)
+				reRandomTrigger, _ := utils.RawToBigInt(nc.Donations.ReRandomAmount)
 				glog.Error(amountBig)
 				glog.Error(reRandomTrigger)
 				glog.Error(amountBig.Cmp(reRandomTrigger))
@@ -101,7 +106,7 @@ func (nc NanoController) Callback(confirmationResponse net.ConfirmationResponse)
 			}
 			nc.SIOServer.BroadcastToRoom("", "bcast", "randomize_event", data)
 			// Refund amount
-			wallet := utils.GetEnv("WALLET_ID", "")
+			wallet := nc.WalletID
 			if wallet == "" {
 				glog.Warningf("Not issuing refund for %s because WALLET_ID is not configured", hash)
 				return
@@ -124,9 +129,11 @@ func (nc NanoController) Callback(confirmationResponse net.ConfirmationResponse)
 				wallet,
 			)
 			if err != nil {
+				metrics.RefundFailed()
 				glog.Errorf("Failed to issue re-randomization refund of %s to %s for %s", amount, block["account"].(string), hash)
 				return
 			}
+			metrics.RefundSent()
 			glog.Infof("Issued refund with hash %s", response.Block)
 		} else {
 			// Emit SIO event
@@ -184,7 +191,7 @@ func (nc NanoController) Callback(confirmationResponse net.ConfirmationResponse)
 				}
 				glog.Infof("Going to refund %s raw to %s", refundRaw, block["account"])
 				// Send refund
-				wallet := utils.GetEnv("WALLET_ID", "")
+				wallet := nc.WalletID
 				if wallet == "" {
 					glog.Warningf("Not issuing refund for %s because WALLET_ID is not configured", hash)
 					return
@@ -197,9 +204,11 @@ func (nc NanoController) Callback(confirmationResponse net.ConfirmationResponse)
 					wallet,
 				)
 				if err != nil {
+					metrics.RefundFailed()
 					glog.Errorf("Failed to issue refund of %s to %s for %s", refundRaw, block["account"].(string), hash)
 					return
 				}
+				metrics.RefundSent()
 				glog.Infof("Issued refund with hash %s", response.Block)
 			}
 		}
@@ -239,13 +248,14 @@ func (nc NanoController) CheckMissedCallbacks() {
 			}
 		}
 	}
+	metrics.CronSucceeded("check_missed_callbacks")
 }
 
 // calcDonorDurationDays - calculate how long badge will persist with given donation amount
 func (nc NanoController) calcDonorDurationDays(amountRaw string) uint {
 	amountNano, _ := utils.RawToNano(amountRaw, true)
 	// TODO - allow partial chunks?
-	chunks := uint(amountNano / donationThresholdNano)
+	chunks := uint(amountNano / nc.Donations.ThresholdNano)
 	return chunks * 30
 }
 
@@ -266,9 +276,10 @@ func (nc NanoController) UpdatePrincipalWeight() {
 		return
 	}
 	// 0.1% of online weight means principal rep
-	principalRepMinimum := onlineWeightMinimum * 0.001
+	principalRepMinimum := onlineWeightMinimum * principalRepWeightRatio
 	glog.Infof("Setting principal rep requirement to %f", principalRepMinimum)
 	db.GetDB().SetPrincipalRepRequirement(principalRepMinimum)
+	metrics.CronSucceeded("update_principal_weight")
 }
 
 // Cron job for updating principal reps
@@ -276,6 +287,16 @@ func (nc NanoController) UpdatePrincipalReps() {
 	if nc.RPCClient == nil {
 		return
 	}
+	// Only one instance should record status changes, the others pick up what it cached
+	lock, err := db.GetDB().Locker.Obtain("pawnimal:principal_reps_lock", 100*time.Second, nil)
+	if err == redislock.ErrNotObtained {
+		image.GetBadgeSvc().UpdatePrincipalReps(db.GetDB().GetPrincipalReps())
+		return
+	} else if err != nil {
+		glog.Error(err)
+		return
+	}
+	defer lock.Release()
 	glog.Infof("Updating principal rep list")
 	// Get weight requirement
 	repWeightRequirement := db.GetDB().GetPrincipalRepRequirement()
@@ -285,19 +306,83 @@ func (nc NanoController) UpdatePrincipalReps() {
 		glog.Errorf("Error occured checking confirmation quorum %s", err)
 		return
 	}
-	principalReps := []string{}
+	weights := map[string]float64{}
+	accounts := map[string]string{}
 	for rep, weight := range repsResponse.Representatives {
 		weightNano, err := utils.RawToNano(weight, true)
 		if err != nil {
 			glog.Errorf("Error occured checking weight for rep %s %s", rep, err)
 			continue
 		}
-		if weightNano >= repWeightRequirement {
-			principalReps = append(principalReps, utils.AddressToPub(rep))
-		}
+		pubkey := utils.AddressToPub(rep)
+		weights[pubkey] = weightNano
+		accounts[pubkey] = rep
+	}
+	principalReps := rankPrincipalReps(weights, accounts, repWeightRequirement)
+	if err := db.GetDB().SetRepWeights(weights); err != nil {
+		glog.Errorf("Error saving rep weights %s", err)
+	}
+	// Record reps that gained or lost principal status since last run
+	changes := repStatusChanges(db.GetDB().GetPrincipalReps(), principalReps, weights, time.Now().UTC())
+	for pubkey, change := range changes {
+		db.GetDB().AddRepStatusChange(pubkey, change)
 	}
 	// Update cache
 	db.GetDB().SetPrincipalReps(principalReps)
 	// Update badge service
 	image.GetBadgeSvc().UpdatePrincipalReps(principalReps)
+	metrics.CronSucceeded("update_principal_reps")
+}
+
+// rankPrincipalReps - reps meeting the weight requirement, highest weight first, the top reps get their own badge
+func rankPrincipalReps(weights map[string]float64, accounts map[string]string, requirement float64) []db.Rep {
+	principalReps := []db.Rep{}
+	for pubkey, weight := range weights {
+		if weight >= requirement {
+			principalReps = append(principalReps, db.Rep{
+				PubKey:  pubkey,
+				Account: accounts[pubkey],
+				Weight:  weight,
+				Badge:   spc.BTNode,
+			})
+		}
+	}
+	sort.Slice(principalReps, func(i, j int) bool {
+		if principalReps[i].Weight == principalReps[j].Weight {
+			return principalReps[i].PubKey < principalReps[j].PubKey
+		}
+		return principalReps[i].Weight > principalReps[j].Weight
+	})
+	for i := 0; i < len(principalReps) && i < topRepCount; i++ {
+		principalReps[i].Badge = spc.BTNodeTop
+	}
+	return principalReps
+}
+
+// repStatusChanges - history entries for reps that gained or lost principal status between previous and current
+func repStatusChanges(previous []db.Rep, current []db.Rep, weights map[string]float64, now time.Time) map[string]db.RepStatusChange {
+	changes := map[string]db.RepStatusChange{}
+	lost := map[string]bool{}
+	for _, rep := range previous {
+		lost[rep.PubKey] = true
+	}
+	for _, rep := range current {
+		if lost[rep.PubKey] {
+			delete(lost, rep.PubKey)
+			continue
+		}
+		changes[rep.PubKey] = db.RepStatusChange{
+			Principal: true,
+			Weight:    rep.Weight,
+			ChangedAt: now,
+		}
+	}
+	for pubkey := range lost {
+		changes[pubkey] = db.RepStatusChange{
+			Principal: false,
+			Weight:    weights[pubkey],
+			ChangedAt: now,
+		}
+	}
+	return changes
 }
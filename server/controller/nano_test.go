@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/paw-digital/Pawnimals/server/db"
+	"github.com/paw-digital/Pawnimals/server/spc"
+)
+
+func TestRankPrincipalReps(t *testing.T) {
+	// Reps weighing 1 to 15, so the 11th heaviest weighs 5
+	weights := map[string]float64{}
+	accounts := map[string]string{}
+	for i := 1; i <= 15; i++ {
+		pubkey := fmt.Sprintf("rep%02d", i)
+		weights[pubkey] = float64(i)
+		accounts[pubkey] = "nano_" + pubkey
+	}
+	tests := []struct {
+		requirement float64
+		principal   int
+		badges      map[string]spc.BadgeType
+	}{
+		// Weight equal to the requirement is enough
+		{3, 13, map[string]spc.BadgeType{"rep15": spc.BTNodeTop, "rep06": spc.BTNodeTop, "rep05": spc.BTNode, "rep03": spc.BTNode, "rep02": spc.BTNone}},
+		{3.5, 12, map[string]spc.BadgeType{"rep04": spc.BTNode, "rep03": spc.BTNone}},
+		// Fewer principal reps than top slots, every one is top
+		{12, 4, map[string]spc.BadgeType{"rep12": spc.BTNodeTop, "rep11": spc.BTNone}},
+		{100, 0, map[string]spc.BadgeType{"rep15": spc.BTNone}},
+	}
+	for _, test := range tests {
+		reps := rankPrincipalReps(weights, accounts, test.requirement)
+		if len(reps) != test.principal {
+			t.Errorf("Expected %d principal reps at %g but got %d", test.principal, test.requirement, len(reps))
+		}
+		badges := map[string]spc.BadgeType{}
+		for i, rep := range reps {
+			badges[rep.PubKey] = rep.Badge
+			if i > 0 && rep.Weight > reps[i-1].Weight {
+				t.Errorf("Expected reps sorted by weight but %s follows %s", rep.PubKey, reps[i-1].PubKey)
+			}
+			if rep.Account != accounts[rep.PubKey] {
+				t.Errorf("Expected account %s but got %s", accounts[rep.PubKey], rep.Account)
+			}
+		}
+		for pubkey, expected := range test.badges {
+			got, ok := badges[pubkey]
+			if !ok {
+				got = spc.BTNone
+			}
+			if got != expected {
+				t.Errorf("Expected %s to get %q at %g but got %q", pubkey, expected, test.requirement, got)
+			}
+		}
+	}
+}
+
+func TestRepStatusChanges(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	weights := map[string]float64{"kept": 10, "gained": 8, "lost": 2}
+	previous := []db.Rep{{PubKey: "kept", Weight: 9}, {PubKey: "lost", Weight: 4}}
+	current := []db.Rep{{PubKey: "kept", Weight: 10}, {PubKey: "gained", Weight: 8}}
+
+	changes := repStatusChanges(previous, current, weights, now)
+	expected := map[string]db.RepStatusChange{
+		"gained": {Principal: true, Weight: 8, ChangedAt: now},
+		"lost":   {Principal: false, Weight: 2, ChangedAt: now},
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("Expected %v but got %v", expected, changes)
+	}
+	for pubkey, change := range expected {
+		if changes[pubkey] != change {
+			t.Errorf("Expected %v for %s but got %v", change, pubkey, changes[pubkey])
+		}
+	}
+
+	// A first run records every rep as gained, an unchanged set records nothing
+	if changes := repStatusChanges(nil, current, weights, now); len(changes) != 2 || !changes["kept"].Principal {
+		t.Errorf("Expected every rep to be gained on the first run but got %v", changes)
+	}
+	if changes := repStatusChanges(current, current, weights, now); len(changes) != 0 {
+		t.Errorf("Expected no changes but got %v", changes)
+	}
+	// Reps that dropped out of the node's list entirely lose status with no weight
+	if changes := repStatusChanges(previous, nil, map[string]float64{}, now); changes["lost"].Principal || changes["lost"].Weight != 0 {
+		t.Errorf("Expected lost with no weight but got %v", changes["lost"])
+	}
+}
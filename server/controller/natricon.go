@@ -1,27 +1,31 @@
 package controller
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/paw-digital/Pawnimals/server/color"
+	"github.com/paw-digital/Pawnimals/server/config"
 	"github.com/paw-digital/Pawnimals/server/db"
 	"github.com/paw-digital/Pawnimals/server/image"
 	"github.com/paw-digital/Pawnimals/server/magickwand"
+	"github.com/paw-digital/Pawnimals/server/metrics"
+	"github.com/paw-digital/Pawnimals/server/middleware"
 	"github.com/paw-digital/Pawnimals/server/spc"
 	"github.com/paw-digital/Pawnimals/server/utils"
 	"github.com/gin-gonic/gin"
 )
 
-const defaultRasterSize = 128 // Default size of PNG/WEBP images
-const minConvertedSize = 100  // Minimum size of PNG/WEBP converted output
-const maxConvertedSize = 1000 // Maximum size of PNG/WEBP converted output
-
 type NatriconController struct {
-	Seed         string
-	StatsChannel *chan *gin.Context
+	Seed       string
+	Stats      *StatsQueue
+	Raster     config.Raster
+	Generation image.Generation         // Color scheme and constraints unless a request asks for others
+	Proxies    middleware.TrustedProxies // Load balancers whose X-Forwarded-For names the client in stats
 }
 
 // APIs
@@ -58,15 +62,171 @@ func (nc NatriconController) GetNano(c *gin.Context) {
 	}
 
 	// Parse stats
-	*nc.StatsChannel <- c
+	nc.Stats.Enqueue(db.StatsEvent{
+		Address:  address,
+		ClientIP: nc.Proxies.ClientIP(c.Request),
+		Service:  c.GetString(middleware.ServiceKey),
+		At:       time.Now().UTC(),
+	})
+
+	gen, err := nc.parseGeneration(c)
+	if err != nil {
+		c.String(http.StatusBadRequest, "%s", err.Error())
+		return
+	}
+	nc.generateIcon(nc.resolveAddress(address, nonce, gen), c)
+}
+
+// GetAltText - title and description of the natricon for an address, for use as alt text
+func (nc NatriconController) GetAltText(c *gin.Context) {
+	address := c.Query("address")
+	nonce, err := strconv.Atoi(c.Query("nonce"))
+	if err != nil {
+		nonce = db.NoNonceApplied
+	}
+	valid := utils.ValidateAddress(address)
+	if !valid {
+		c.String(http.StatusBadRequest, "Invalid address")
+		return
+	}
+
+	gen, err := nc.parseGeneration(c)
+	if err != nil {
+		c.String(http.StatusBadRequest, "%s", err.Error())
+		return
+	}
+	accessories, err := nc.resolveAddress(address, nonce, gen).accessories(false, nil)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "%s", err.Error())
+		return
+	}
+	alt := image.GetAltText(accessories, address)
+	c.JSON(200, gin.H{
+		"address":     address,
+		"title":       alt.Title,
+		"description": alt.Description,
+	})
+}
 
-	var sha256 string
-	var badgeType spc.BadgeType
-	specialNatricon := false
+// GetTraits - traits of an account's natricon with how rare each one is
+func (nc NatriconController) GetTraits(c *gin.Context) {
+	address := c.Query("address")
+	nonce, err := strconv.Atoi(c.Query("nonce"))
+	if err != nil {
+		nonce = db.NoNonceApplied
+	}
+	if !utils.ValidateAddress(address) {
+		c.String(http.StatusBadRequest, "Invalid address")
+		return
+	}
+	gen, err := nc.parseGeneration(c)
+	if err != nil {
+		c.String(http.StatusBadRequest, "%s", err.Error())
+		return
+	}
+	accessories, err := nc.resolveAddress(address, nonce, gen).accessories(false, nil)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "%s", err.Error())
+		return
+	}
+	rarity := image.GetRarity(accessories, image.GetRarityTable(gen))
+	c.JSON(200, gin.H{
+		"address": address,
+		"traits":    rarity.Traits,
+		"legendary": accessories.Legendary,
+		"score":     rarity.Score,
+		"one_in":    rarity.OneIn,
+	})
+}
+
+// GetPalette - design tokens for theming a page around an account's natricon
+func (nc NatriconController) GetPalette(c *gin.Context) {
+	address := c.Query("address")
+	nonce, err := strconv.Atoi(c.Query("nonce"))
+	if err != nil {
+		nonce = db.NoNonceApplied
+	}
+	if !utils.ValidateAddress(address) {
+		c.String(http.StatusBadRequest, "Invalid address")
+		return
+	}
+	gen, err := nc.parseGeneration(c)
+	if err != nil {
+		c.String(http.StatusBadRequest, "%s", err.Error())
+		return
+	}
+	accessories, err := nc.resolveAddress(address, nonce, gen).accessories(false, nil)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "%s", err.Error())
+		return
+	}
+	palette := image.GetPalette(accessories)
+	c.JSON(200, gin.H{
+		"address":          address,
+		"primary":          palette.Primary.ToHTML(true),
+		"accent":           palette.Accent.ToHTML(true),
+		"on_primary":       palette.OnPrimary.ToHTML(true),
+		"on_accent":        palette.OnAccent.ToHTML(true),
+		"surface_light":    palette.SurfaceLight.ToHTML(true),
+		"surface_dark":     palette.SurfaceDark.ToHTML(true),
+		"on_surface_light": palette.OnSurfaceLight.ToHTML(true),
+		"on_surface_dark":  palette.OnSurfaceDark.ToHTML(true),
+		"contrast":         palette.Contrast(),
+	})
+}
+
+// GetSimilarity - how alike the natricons of accounts a and b look, to help spot look-alike addresses
+func (nc NatriconController) GetSimilarity(c *gin.Context) {
+	a := c.Query("a")
+	b := c.Query("b")
+	if !utils.ValidateAddress(a) || !utils.ValidateAddress(b) {
+		c.String(http.StatusBadRequest, "Invalid address")
+		return
+	}
+	gen, err := nc.parseGeneration(c)
+	if err != nil {
+		c.String(http.StatusBadRequest, "%s", err.Error())
+		return
+	}
+	accessoriesA, err := nc.resolveAddress(a, db.NoNonceApplied, gen).accessories(false, nil)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "%s", err.Error())
+		return
+	}
+	accessoriesB, err := nc.resolveAddress(b, db.NoNonceApplied, gen).accessories(false, nil)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "%s", err.Error())
+		return
+	}
+	sim := image.Compare(accessoriesA, accessoriesB)
+	c.JSON(200, gin.H{
+		"a":             a,
+		"b":             b,
+		"score":         sim.Score,
+		"verdict":       sim.Verdict,
+		"same_face":     sim.SameFace,
+		"body_distance": sim.BodyDistance,
+		"hair_distance": sim.HairDistance,
+	})
+}
+
+// iconSource - what an address renders as, either a hash or a hand picked vanity
+type iconSource struct {
+	hash      string
+	vanity    *spc.Vanity // Set for special natricons
+	badgeType spc.BadgeType
+	gen       image.Generation // Colors of hashes, vanities have their own
+	special   bool             // Vanities never get a legendary variant, even those with a hash
+}
+
+// resolveAddress - find the hash or vanity and badge for an address, applying its nonce
+// nonce of -1 ignores any stored nonce and NoNonceApplied looks it up
+func (nc NatriconController) resolveAddress(address string, nonce int, gen image.Generation) iconSource {
+	src := iconSource{gen: gen}
 	pubKey := utils.AddressToPub(address)
 	vanity := spc.Vanities[pubKey]
 	if vanity == nil {
-		badgeType = image.GetBadgeSvc().GetBadgeType(pubKey)
+		src.badgeType = image.GetBadgeSvc().GetBadgeType(pubKey)
 		if nonce != -1 {
 			if nonce == db.NoNonceApplied {
 				nonce = db.GetDB().GetNonce(pubKey)
@@ -75,26 +235,59 @@ func (nc NatriconController) GetNano(c *gin.Context) {
 				pubKey = fmt.Sprintf("%s:%s", strconv.Itoa(nonce), pubKey)
 			}
 		}
-		sha256 = utils.PKSha256(pubKey, nc.Seed)
+		src.hash = utils.PKSha256(pubKey, nc.Seed)
 	} else {
-		badgeType = vanity.Badge
-		if badgeType == "" {
-			badgeType = spc.BTNone
+		src.special = true
+		src.badgeType = vanity.Badge
+		if src.badgeType == "" {
+			src.badgeType = spc.BTNone
 		}
 		if vanity.FaceAssetID > 0 && vanity.BodyColor != nil && vanity.HairColor != nil {
-			specialNatricon = true
+			src.vanity = vanity
 		} else if vanity.Hash == "" {
-			sha256 = utils.PKSha256(pubKey, nc.Seed)
+			src.hash = utils.PKSha256(pubKey, nc.Seed)
 		} else {
-			sha256 = vanity.Hash
+			src.hash = vanity.Hash
 		}
 	}
+	return src
+}
 
-	if specialNatricon {
-		generateSpecialIcon(vanity, badgeType, c)
-	} else {
-		generateIcon(&sha256, badgeType, c)
+// accessories - traits of the icon
+func (src iconSource) accessories(outline bool, outlineColor *color.RGB) (image.Accessories, error) {
+	if src.vanity != nil {
+		v := src.vanity
+		accessories := image.GetSpecificNatricon(src.badgeType, outline, outlineColor, v.BodyColor, v.HairColor, v.FaceAssetID, v.HairAssetID, v.MouthAssetID, v.EyeAssetID)
+		// Badges only fit faces since the manifest says which, older schemes keep drawing vanities without them
+		if !src.gen.Scheme.Badges() {
+			accessories.BadgeAsset = nil
+		}
+		return accessories, nil
+	}
+	accessories, err := image.GetAccessoriesWith(src.hash, src.gen, src.badgeType, outline, outlineColor)
+	if src.special {
+		accessories.Legendary = image.LegendaryNone
 	}
+	return accessories, err
+}
+
+// parseGeneration - scheme and cvd_safe options, configured ones if omitted
+func (nc NatriconController) parseGeneration(c *gin.Context) (image.Generation, error) {
+	gen := nc.Generation
+	if c.Query("scheme") != "" {
+		var err error
+		gen.Scheme, err = image.ParseScheme(c.Query("scheme"))
+		if err != nil {
+			return gen, err
+		}
+	}
+	switch strings.ToLower(c.Query("cvd_safe")) {
+	case "true":
+		gen.CVDSafe = true
+	case "false":
+		gen.CVDSafe = false
+	}
+	return gen, nil
 }
 
 // Testing APIs
@@ -217,122 +410,235 @@ func (nc NatriconController) GetNatricon(c *gin.Context) {
 	})
 }
 
-// Generate natricon with given hash
-func generateIcon(hash *string, badgeType spc.BadgeType, c *gin.Context) {
-	var err error
+// parseOutline - outline=true with outline_color as hex or rgb() (white if omitted) and outline_width in canvas units
+func parseOutline(c *gin.Context) (bool, *color.RGB, float64, error) {
+	if strings.ToLower(c.Query("outline")) != "true" {
+		return false, nil, 0, nil
+	}
+	outlineColor := &color.RGB{R: 255.0, G: 255.0, B: 255.0}
+	if colorStr := c.Query("outline_color"); colorStr != "" {
+		parsed, err := color.ParseColor(colorStr)
+		if err != nil {
+			return false, nil, 0, errors.New("outline_color must be a hex color like #1a2b3c or rgb(26, 43, 60)")
+		}
+		outlineColor = &parsed
+	}
+	outlineWidth := image.DefaultOutlineWidth
+	if widthStr := c.Query("outline_width"); widthStr != "" {
+		width, err := strconv.ParseFloat(widthStr, 64)
+		if err != nil || width <= 0 || width > image.MaxOutlineWidth {
+			return false, nil, 0, fmt.Errorf("outline_width must be a number greater than 0 and at most %g", image.MaxOutlineWidth)
+		}
+		outlineWidth = width
+	}
+	return true, outlineColor, outlineWidth, nil
+}
 
-	format := strings.ToLower(c.Query("format"))
-	size := 0
-	if format == "" || format == "svg" {
-		format = "svg"
-	} else if format != "png" && format != "webp" {
-		c.String(http.StatusBadRequest, "%s", "Valid formats are 'svg', 'png', or 'webp'")
-		return
-	} else {
-		sizeStr := c.Query("size")
-		if sizeStr == "" {
-			size = defaultRasterSize
-		} else {
-			size, err = strconv.Atoi(c.Query("size"))
-			if err != nil || size < minConvertedSize || size > maxConvertedSize {
-				c.String(http.StatusBadRequest, "%s", fmt.Sprintf("size must be an integer between %d and %d", minConvertedSize, maxConvertedSize))
-				return
+// parseFrame - background color, optionally background_to for a top to bottom gradient, shape, padding in percent and shadow
+func parseFrame(c *gin.Context) (image.Frame, error) {
+	var frame image.Frame
+	if c.Query("background_to") != "" && c.Query("background") == "" {
+		return frame, errors.New("background_to needs a background to fade from")
+	}
+	if bg := c.Query("background"); bg != "" {
+		parsed, err := color.ParseColor(bg)
+		if err != nil {
+			return frame, errors.New("background must be a hex color like #1a2b3c or rgb(26, 43, 60)")
+		}
+		frame.Background = &parsed
+		if bgTo := c.Query("background_to"); bgTo != "" {
+			parsed, err := color.ParseColor(bgTo)
+			if err != nil {
+				return frame, errors.New("background_to must be a hex color like #1a2b3c or rgb(26, 43, 60)")
 			}
+			frame.BackgroundTo = &parsed
 		}
 	}
-
-	outline := strings.ToLower(c.Query("outline")) == "true"
-	// Get outline and outline color info, black is default
-	var outlineColor *color.RGB
-	if outline {
-		if strings.ToLower(c.Query("outline_color")) == "black" {
-			outlineColor = &color.RGB{R: 0.0, G: 0.0, B: 0.0}
-		} else {
-			outlineColor = &color.RGB{R: 255.0, G: 255.0, B: 255.0}
+	if shape := strings.ToLower(c.Query("shape")); shape != "" && shape != "square" {
+		for _, s := range image.Shapes {
+			if string(s) == shape {
+				frame.Shape = s
+			}
+		}
+		if frame.Shape == image.ShapeNone {
+			return frame, errors.New("Valid shapes are 'square', 'circle', 'rounded' or 'squircle'")
 		}
 	}
+	if paddingStr := c.Query("padding"); paddingStr != "" {
+		padding, err := strconv.ParseFloat(paddingStr, 64)
+		if err != nil || padding < 0 || padding > image.MaxPadding*100 {
+			return frame, fmt.Errorf("padding must be a percentage between 0 and %g", image.MaxPadding*100)
+		}
+		frame.Padding = padding / 100
+	}
+	frame.Shadow = strings.ToLower(c.Query("shadow")) == "true"
+	return frame, nil
+}
 
-	accessories, err := image.GetAccessoriesForHash(*hash, badgeType, outline, outlineColor)
+// iconStyle - rendering options shared by every icon endpoint
+type iconStyle struct {
+	outline      bool
+	outlineColor *color.RGB
+	outlineWidth float64
+	frame        image.Frame
+	accessible   bool
+	inline       bool
+	instance     string // Tells apart copies of one icon inlined on the same page
+	displaySize  int    // Pixels an svg is shown at, rasters use their own size
+	drawStyle    image.Style
+	cvd          color.Deficiency // Preview as seen with this deficiency, empty for normal vision
+}
+
+// parseStyle - outline, frame, accessible, inline, instance, style and cvd query options
+func parseStyle(c *gin.Context) (iconStyle, error) {
+	var style iconStyle
+	var err error
+	style.outline, style.outlineColor, style.outlineWidth, err = parseOutline(c)
 	if err != nil {
-		c.String(http.StatusInternalServerError, "%s", err.Error())
-		return
+		return style, err
 	}
-	bodyHsv := accessories.BodyColor.ToHSB()
-	hairHsv := accessories.HairColor.ToHSB()
-	deltaHsv := color.HSB{}
-	deltaHsv.H = hairHsv.H - bodyHsv.H
-	deltaHsv.S = hairHsv.S - bodyHsv.S
-	deltaHsv.B = hairHsv.B - bodyHsv.B
-	svg, err := image.CombineSVG(accessories)
+	style.frame, err = parseFrame(c)
 	if err != nil {
-		c.String(http.StatusInternalServerError, "Error occured")
-		return
+		return style, err
+	}
+	style.accessible = strings.ToLower(c.Query("accessible")) == "true"
+	style.inline = strings.ToLower(c.Query("inline")) == "true"
+	style.instance = c.Query("instance")
+	style.drawStyle, err = image.ParseStyle(c.Query("style"))
+	if err != nil {
+		return style, err
 	}
-	if format != "svg" {
-		// Convert
-		var converted []byte
-		converted, err = magickwand.ConvertSvgToBinary(svg, magickwand.ImageFormat(format), uint(size))
+	if c.Query("cvd") != "" {
+		style.cvd, err = color.ParseDeficiency(c.Query("cvd"))
 		if err != nil {
-			c.String(http.StatusInternalServerError, "Error occured")
-			return
+			return style, err
 		}
-		c.Data(200, fmt.Sprintf("image/%s", format), converted)
-		return
 	}
-	c.Data(200, "image/svg+xml; charset=utf-8", svg)
+	if sizeStr := c.Query("display_size"); sizeStr != "" {
+		size, err := strconv.Atoi(sizeStr)
+		if err != nil || size < 1 {
+			return style, errors.New("display_size must be a positive integer")
+		}
+		style.displaySize = size
+	}
+	return style, nil
 }
 
-// Generate icon for special accounts
-func generateSpecialIcon(vanity *spc.Vanity, badgeType spc.BadgeType, c *gin.Context) {
-	var err error
+// render - svg of src with style applied, for format at size
+func (style iconStyle) render(src iconSource, format string, size int, address string) ([]byte, error) {
+	accessories, err := src.accessories(style.outline, style.outlineColor)
+	if err != nil {
+		return nil, err
+	}
+	accessories.OutlineWidth = style.outlineWidth
+	accessories.Frame = style.frame
+	accessories.DisplaySize = style.displaySize
+	accessories.Style = style.drawStyle
+	accessories.CVD = style.cvd
+	if size > 0 {
+		accessories.DisplaySize = size
+	}
+	// JPEG has no transparency
+	if format == "jpeg" && accessories.Frame.Background == nil {
+		accessories.Frame.Background = &color.RGB{R: 255.0, G: 255.0, B: 255.0}
+	}
+	if style.accessible {
+		alt := image.GetAltText(accessories, address)
+		accessories.AltText = &alt
+	}
+	svg, err := combineSVG(accessories)
+	if err != nil {
+		return nil, err
+	}
+	// Namespace ids so the svg can be inlined next to other icons
+	if format == "svg" && style.inline {
+		svg = image.NamespaceIDs(svg, image.InlineIDPrefix(svg, style.instance))
+	}
+	return svg, nil
+}
 
+// parseFormat - format and size, size is 0 for svg and ico which has fixed sizes and in columns for ansi
+func (nc NatriconController) parseFormat(c *gin.Context) (string, int, error) {
 	format := strings.ToLower(c.Query("format"))
-	size := 0
-	if format == "" || format == "svg" {
-		format = "svg"
-	} else if format != "png" && format != "webp" {
-		c.String(http.StatusBadRequest, "%s", "Valid formats are 'svg', 'png', or 'webp'")
-		return
-	} else {
-		sizeStr := c.Query("size")
+	switch format {
+	case "", "svg":
+		return "svg", 0, nil
+	case "jpg":
+		format = "jpeg"
+	}
+	if _, ok := iconFormats[format]; !ok {
+		return "", 0, errors.New("Valid formats are 'svg', 'png', 'webp', 'avif', 'jpeg', 'ico' or 'ansi'")
+	}
+	if format == "ico" {
+		return format, 0, nil
+	}
+	sizeStr := c.Query("size")
+	// Terminal output is sized in columns
+	if format == "ansi" {
 		if sizeStr == "" {
-			size = defaultRasterSize
-		} else {
-			size, err = strconv.Atoi(c.Query("size"))
-			if err != nil || size < minConvertedSize || size > maxConvertedSize {
-				c.String(http.StatusBadRequest, "%s", fmt.Sprintf("size must be an integer between %d and %d", minConvertedSize, maxConvertedSize))
-				return
-			}
+			return format, magickwand.DefaultANSIWidth, nil
 		}
+		size, err := strconv.Atoi(sizeStr)
+		if err != nil || size < magickwand.MinANSIWidth || size > magickwand.MaxANSIWidth {
+			return "", 0, fmt.Errorf("size must be a number of columns between %d and %d", magickwand.MinANSIWidth, magickwand.MaxANSIWidth)
+		}
+		return format, size, nil
+	}
+	if sizeStr == "" {
+		return format, nc.Raster.DefaultSize, nil
+	}
+	size, err := strconv.Atoi(sizeStr)
+	if err != nil || size < nc.Raster.MinSize || size > nc.Raster.MaxSize {
+		return "", 0, fmt.Errorf("size must be an integer between %d and %d", nc.Raster.MinSize, nc.Raster.MaxSize)
 	}
+	return format, size, nil
+}
 
-	outline := strings.ToLower(c.Query("outline")) == "true"
-	// Get outline and outline color info, black is default
-	var outlineColor *color.RGB
-	if outline {
-		if strings.ToLower(c.Query("outline_color")) == "black" {
-			outlineColor = &color.RGB{R: 0.0, G: 0.0, B: 0.0}
-		} else {
-			outlineColor = &color.RGB{R: 255.0, G: 255.0, B: 255.0}
-		}
+// Generate natricon for a hash or vanity
+func (nc NatriconController) generateIcon(src iconSource, c *gin.Context) {
+	format, size, err := nc.parseFormat(c)
+	if err != nil {
+		c.String(http.StatusBadRequest, "%s", err.Error())
+		return
 	}
+	if format != "svg" && !magickwand.Supports(format) {
+		c.String(http.StatusNotImplemented, "%s", fmt.Sprintf("%s is not supported by this server", format))
+		return
+	}
+	start := time.Now()
+	defer metrics.ObserveIconRequest(format, size, start)
 
-	accessories := image.GetSpecificNatricon(badgeType, outline, outlineColor, vanity.BodyColor, vanity.HairColor, vanity.FaceAssetID, vanity.HairAssetID, vanity.MouthAssetID, vanity.EyeAssetID)
-	svg, err := image.CombineSVG(accessories)
+	style, err := parseStyle(c)
 	if err != nil {
-		c.String(http.StatusInternalServerError, "Error occured")
+		c.String(http.StatusBadRequest, "%s", err.Error())
 		return
 	}
-	if format != "svg" {
-		// Convert
-		var converted []byte
-		converted, err = magickwand.ConvertSvgToBinary(svg, magickwand.ImageFormat(format), uint(size))
+	if format == "ico" {
+		ico, err := style.ico(src, c.Query("address"))
 		if err != nil {
 			c.String(http.StatusInternalServerError, "Error occured")
 			return
 		}
-		c.Data(200, fmt.Sprintf("image/%s", format), converted)
+		c.Data(200, iconFormats[format], ico)
 		return
 	}
-	c.Data(200, "image/svg+xml; charset=utf-8", svg)
+	svg, err := style.render(src, format, size, c.Query("address"))
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Error occured")
+		return
+	}
+	writeIcon(c, svg, format, size)
+}
+
+// ico - an ico of src with every size in it rendered for that size
+func (style iconStyle) ico(src iconSource, address string) ([]byte, error) {
+	svgs := make([][]byte, len(magickwand.IcoSizes))
+	for i, size := range magickwand.IcoSizes {
+		svg, err := style.render(src, "ico", int(size), address)
+		if err != nil {
+			return nil, err
+		}
+		svgs[i] = svg
+	}
+	return rasterizeIco(svgs)
 }
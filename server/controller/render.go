@@ -0,0 +1,162 @@
+package controller
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/paw-digital/Pawnimals/server/image"
+	"github.com/paw-digital/Pawnimals/server/magickwand"
+	"github.com/paw-digital/Pawnimals/server/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// Rasterization is by far the most expensive step, keep converted icons around up to this many bytes
+var rasterCacheBytes = 64 << 20
+
+// ConfigureRasterCache - bytes of converted icons to keep in memory, 0 turns the cache off. Call before serving
+func ConfigureRasterCache(maxBytes int) {
+	rasterCacheBytes = maxBytes
+}
+
+// combineSVG - image.CombineSVG with timing and error metrics
+func combineSVG(accessories image.Accessories) ([]byte, error) {
+	start := time.Now()
+	svg, err := image.CombineSVG(accessories)
+	if err != nil {
+		metrics.RenderError("combine")
+		return nil, err
+	}
+	metrics.ObserveRender("combine", "svg", start)
+	return svg, nil
+}
+
+// Content type of every format an icon can be served as
+var iconFormats = map[string]string{
+	"svg":  "image/svg+xml; charset=utf-8",
+	"png":  "image/png",
+	"webp": "image/webp",
+	"avif": "image/avif",
+	"jpeg": "image/jpeg",
+	"ico":  "image/x-icon",
+	"ansi": "text/plain; charset=utf-8",
+}
+
+// writeIcon - write svg to the response, rasterizing it to format first unless format is svg
+func writeIcon(c *gin.Context, svg []byte, format string, size int) {
+	if format == "svg" {
+		c.Data(200, iconFormats["svg"], svg)
+		return
+	}
+	converted, err := rasterize(svg, format, size)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Error occured")
+		return
+	}
+	c.Data(200, iconFormats[format], converted)
+}
+
+// rasterize - convert svg to format at size, for ansi size is the width in characters
+func rasterize(svg []byte, format string, size int) ([]byte, error) {
+	key := fmt.Sprintf("%x:%s:%d", sha256.Sum256(svg), format, size)
+	return cachedConvert(key, format, func() ([]byte, error) {
+		if format == "ansi" {
+			return magickwand.ConvertSvgToANSI(svg, uint(size))
+		}
+		return magickwand.ConvertSvgToBinary(svg, magickwand.ImageFormat(format), uint(size))
+	})
+}
+
+// rasterizeIco - an ico with svgs rendered at magickwand.IcoSizes
+func rasterizeIco(svgs [][]byte) ([]byte, error) {
+	h := sha256.New()
+	for _, svg := range svgs {
+		h.Write(svg)
+	}
+	key := fmt.Sprintf("%x:ico", h.Sum(nil))
+	return cachedConvert(key, "ico", func() ([]byte, error) {
+		return magickwand.ConvertSvgToIco(svgs, magickwand.IcoSizes)
+	})
+}
+
+// cachedConvert - convert through the raster cache
+func cachedConvert(key string, format string, convert func() ([]byte, error)) ([]byte, error) {
+	converted, ok := getRasterCache().get(key)
+	metrics.CacheHit("raster", ok)
+	if ok {
+		return converted, nil
+	}
+	start := time.Now()
+	converted, err := convert()
+	if err != nil {
+		metrics.RenderError("convert")
+		return nil, err
+	}
+	metrics.ObserveRender("convert", format, start)
+	getRasterCache().add(key, converted)
+	return converted, nil
+}
+
+// rasterCache - LRU of converted icons, bounded by their total size
+type rasterCache struct {
+	mu       sync.Mutex
+	maxBytes int
+	size     int
+	order    *list.List
+	entries  map[string]*list.Element
+}
+
+type rasterCacheEntry struct {
+	key  string
+	data []byte
+}
+
+var rcSingleton *rasterCache
+var rcOnce sync.Once
+
+func getRasterCache() *rasterCache {
+	rcOnce.Do(func() {
+		rcSingleton = &rasterCache{
+			maxBytes: rasterCacheBytes,
+			order:    list.New(),
+			entries:  map[string]*list.Element{},
+		}
+	})
+	return rcSingleton
+}
+
+func (rc *rasterCache) get(key string) ([]byte, bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	el, ok := rc.entries[key]
+	if !ok {
+		return nil, false
+	}
+	rc.order.MoveToFront(el)
+	return el.Value.(*rasterCacheEntry).data, true
+}
+
+func (rc *rasterCache) add(key string, data []byte) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if el, ok := rc.entries[key]; ok {
+		rc.order.MoveToFront(el)
+		return
+	}
+	// Would evict everything else and still not fit
+	if len(data) > rc.maxBytes {
+		return
+	}
+	rc.entries[key] = rc.order.PushFront(&rasterCacheEntry{key: key, data: data})
+	rc.size += len(data)
+	for rc.size > rc.maxBytes {
+		oldest := rc.order.Back()
+		rc.order.Remove(oldest)
+		entry := oldest.Value.(*rasterCacheEntry)
+		delete(rc.entries, entry.key)
+		rc.size -= len(entry.data)
+	}
+}
@@ -0,0 +1,71 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/paw-digital/Pawnimals/server/db"
+	"github.com/paw-digital/Pawnimals/server/image"
+	"github.com/paw-digital/Pawnimals/server/spc"
+	"github.com/paw-digital/Pawnimals/server/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// Reps API - principal reps with their badge tier and status history
+func Reps(c *gin.Context) {
+	address := c.Query("address")
+	if address != "" {
+		repDetails(address, c)
+		return
+	}
+
+	reps := db.GetDB().GetPrincipalReps()
+	pubkeys := make([]string, len(reps))
+	for i, rep := range reps {
+		pubkeys[i] = rep.PubKey
+	}
+	latest := db.GetDB().GetLatestRepStatusChanges(pubkeys)
+	repList := []gin.H{}
+	for i, rep := range reps {
+		entry := gin.H{
+			"account": rep.Account,
+			"weight":  rep.Weight,
+			"badge":   rep.Badge,
+			"rank":    i + 1,
+		}
+		if change, ok := latest[rep.PubKey]; ok && change.Principal {
+			entry["principal_since"] = change.ChangedAt
+		}
+		repList = append(repList, entry)
+	}
+
+	c.JSON(200, gin.H{
+		"principal_requirement": db.GetDB().GetPrincipalRepRequirement(),
+		"reps":                  repList,
+	})
+}
+
+// repDetails - weight, badge and full principal status history of a single rep
+func repDetails(address string, c *gin.Context) {
+	if !utils.ValidateAddress(address) {
+		c.String(http.StatusBadRequest, "Invalid address")
+		return
+	}
+	pubkey := utils.AddressToPub(address)
+	weight, ok := db.GetDB().GetRepWeight(pubkey)
+	if !ok {
+		c.String(http.StatusNotFound, "Not a representative")
+		return
+	}
+	badge, principal := image.GetBadgeSvc().PrincipalReps[pubkey]
+	if !principal {
+		badge = spc.BTNone
+	}
+
+	c.JSON(200, gin.H{
+		"account":   address,
+		"weight":    weight,
+		"principal": principal,
+		"badge":     badge,
+		"history":   db.GetDB().GetRepStatusHistory(pubkey),
+	})
+}
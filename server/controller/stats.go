@@ -2,30 +2,128 @@ package controller
 
 import (
 	"fmt"
+	"math"
+	"net/http"
 	"os"
 	"path"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/paw-digital/Pawnimals/server/db"
 	"github.com/paw-digital/Pawnimals/server/image"
 	"github.com/paw-digital/Pawnimals/server/spc"
 	"github.com/paw-digital/Pawnimals/server/utils"
 	"github.com/gin-gonic/gin"
+	"github.com/golang/glog"
 )
 
-// Go routine for processing stats messages
-func StatsWorker(statsChan <-chan *gin.Context) {
-	// Process stats
-	for c := range statsChan {
-		// Update unique addresses
-		db.GetDB().UpdateStatsAddress(c.Query("address"))
-		// Update daily
-		db.GetDB().UpdateStatsDate(c.Query("address"))
-		// Update clients
-		db.GetDB().UpdateStatsClient(c.ClientIP())
-		// Update by service
-		if c.Query("svc") != "" {
-			db.GetDB().UpdateStatsByService(c.Query("svc"), c.Query("address"))
+// Stats events are written to redis in batches of up to statsBatchSize,
+// or whatever has queued up after statsFlushInterval
+const statsQueueSize = 1000
+const statsBatchSize = 100
+const statsFlushInterval = time.Second
+
+// StatsWriter - writes a batch of stats events, db.GetDB() in production
+type StatsWriter interface {
+	UpdateStatsBatch(events []db.StatsEvent) error
+}
+
+// StatsQueue - buffers stats events between request handlers and the stats worker
+type StatsQueue struct {
+	writer  StatsWriter
+	events  chan db.StatsEvent
+	done    chan struct{}
+	mu      sync.RWMutex // Held for writing while events is closed, so no send can race it
+	closed  bool
+	dropped uint64 // Events dropped because the queue was full or closed
+	failed  uint64 // Events lost to failed redis writes
+}
+
+func NewStatsQueue(writer StatsWriter) *StatsQueue {
+	return &StatsQueue{
+		writer: writer,
+		events: make(chan db.StatsEvent, statsQueueSize),
+		done:   make(chan struct{}),
+	}
+}
+
+// Close - stop accepting events and wait for the worker to flush what is queued
+// Handlers still running after a timed out shutdown may keep calling Enqueue, their events are dropped.
+func (q *StatsQueue) Close() {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.events)
+	}
+	q.mu.Unlock()
+	<-q.done
+}
+
+// Enqueue - queue an event without blocking, the event is dropped if the queue is full or closed
+func (q *StatsQueue) Enqueue(event db.StatsEvent) bool {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		atomic.AddUint64(&q.dropped, 1)
+		return false
+	}
+	select {
+	case q.events <- event:
+		return true
+	default:
+		atomic.AddUint64(&q.dropped, 1)
+		return false
+	}
+}
+
+// Dropped - # of events dropped because the queue was full or closed
+func (q *StatsQueue) Dropped() uint64 {
+	return atomic.LoadUint64(&q.dropped)
+}
+
+// Failed - # of events lost to failed redis writes
+func (q *StatsQueue) Failed() uint64 {
+	return atomic.LoadUint64(&q.failed)
+}
+
+// StatsWorker - Go routine for processing stats events
+func (q *StatsQueue) StatsWorker() {
+	defer close(q.done)
+	ticker := time.NewTicker(statsFlushInterval)
+	defer ticker.Stop()
+	batch := make([]db.StatsEvent, 0, statsBatchSize)
+	lastDropped := uint64(0)
+	for {
+		select {
+		case event, ok := <-q.events:
+			if !ok {
+				q.flush(batch)
+				return
+			}
+			batch = append(batch, event)
+			if len(batch) < statsBatchSize {
+				continue
+			}
+		case <-ticker.C:
+			if dropped := q.Dropped(); dropped != lastDropped {
+				glog.Warningf("Stats queue full, dropped %d events", dropped-lastDropped)
+				lastDropped = dropped
+			}
 		}
+		q.flush(batch)
+		batch = batch[:0]
+	}
+}
+
+// flush - write a batch to redis, failed batches are counted and not retried
+func (q *StatsQueue) flush(batch []db.StatsEvent) {
+	if len(batch) == 0 {
+		return
+	}
+	if err := q.writer.UpdateStatsBatch(batch); err != nil {
+		atomic.AddUint64(&q.failed, uint64(len(batch)))
+		glog.Errorf("Error writing %d stats events %s", len(batch), err)
 	}
 }
 
@@ -50,6 +148,68 @@ func Stats(c *gin.Context) {
 	})
 }
 
+// Longest range a stats history query may span
+const maxStatsHistoryDays = 366
+const maxStatsHistoryHours = 14 * 24
+
+// Stats history API, counts per day or hour between from and to
+func StatsHistory(c *gin.Context) {
+	interval := db.StatsInterval(c.DefaultQuery("interval", string(db.StatsDaily)))
+	var format string
+	var maxRange time.Duration
+	switch interval {
+	case db.StatsDaily:
+		format = "2006-01-02"
+		maxRange = maxStatsHistoryDays * 24 * time.Hour
+	case db.StatsHourly:
+		format = "2006-01-02T15"
+		maxRange = maxStatsHistoryHours * time.Hour
+	default:
+		c.String(http.StatusBadRequest, "interval must be 'day' or 'hour'")
+		return
+	}
+
+	to := time.Now().UTC()
+	if c.Query("to") != "" {
+		parsed, err := time.Parse(format, c.Query("to"))
+		if err != nil {
+			c.String(http.StatusBadRequest, "to must be formatted as %s", format)
+			return
+		}
+		to = parsed
+	}
+	from := to.Add(-30 * 24 * time.Hour)
+	if interval == db.StatsHourly {
+		from = to.Add(-24 * time.Hour)
+	}
+	if c.Query("from") != "" {
+		parsed, err := time.Parse(format, c.Query("from"))
+		if err != nil {
+			c.String(http.StatusBadRequest, "from must be formatted as %s", format)
+			return
+		}
+		from = parsed
+	}
+	if from.After(to) {
+		c.String(http.StatusBadRequest, "from must be before to")
+		return
+	}
+	if to.Sub(from) > maxRange {
+		c.String(http.StatusBadRequest, "range can't be longer than %s", maxRange)
+		return
+	}
+
+	history, err := db.GetDB().StatsHistory(from, to, interval, c.Query("svc"))
+	if err != nil {
+		c.String(http.StatusBadRequest, "%s", err.Error())
+		return
+	}
+	c.JSON(200, gin.H{
+		"interval": interval,
+		"history":  history,
+	})
+}
+
 // For generating CSV documents for algorithm analysis
 func TestBodyDistribution(seed string) {
 	wd, _ := os.Getwd()
@@ -70,7 +230,7 @@ func TestBodyDistribution(seed string) {
 	lt100 := 0
 	for i := 0; i < 10000; i++ {
 		address = utils.GenerateAddress()
-		sha256 = utils.AddressSha256(address, seed)
+		sha256 = utils.PKSha256(utils.AddressToPub(address), seed)
 		accessories, _ = image.GetAccessoriesForHash(sha256, spc.BTNone, false, nil)
 		ret += fmt.Sprintf("%f,%f,%f,%f\n", accessories.BodyColor.ToHSB().H, accessories.BodyColor.ToHSB().S*100.0, accessories.BodyColor.ToHSB().B*100.0, accessories.BodyColor.PerceivedBrightness())
 		if accessories.BodyColor.ToHSB().S*100.0 < 20 {
@@ -112,7 +272,7 @@ func TestHairDistribution(seed string) {
 	lt100 := 0
 	for i := 0; i < 10000; i++ {
 		address = utils.GenerateAddress()
-		sha256 = utils.AddressSha256(address, seed)
+		sha256 = utils.PKSha256(utils.AddressToPub(address), seed)
 		accessories, _ = image.GetAccessoriesForHash(sha256, spc.BTNone, false, nil)
 		ret += fmt.Sprintf("%f,%f,%f,%f\n", accessories.HairColor.ToHSB().H, accessories.HairColor.ToHSB().S*100.0, accessories.HairColor.ToHSB().B*100.0, accessories.HairColor.PerceivedBrightness())
 		if accessories.HairColor.ToHSB().S*100.0 < 20 {
@@ -134,3 +294,45 @@ func TestHairDistribution(seed string) {
 	print(fmt.Sprintf("S 60-80 %d\n", lt80))
 	print(fmt.Sprintf("S 80-100 %d\n", lt100))
 }
+
+// TestSchemeDistribution - CSV of body and hair colors under scheme plus a summary of hue coverage and body/hair contrast
+func TestSchemeDistribution(seed string, gen image.Generation) {
+	wd, _ := os.Getwd()
+	output := path.Join(wd, fmt.Sprintf("scheme_%d_distribution.csv", gen.Scheme))
+	outputF, err := os.Create(output)
+	if err != nil {
+		fmt.Printf("Failed to open file for writing %s", output)
+		return
+	}
+	defer outputF.Close()
+	const samples = 10000
+	const hueBins = 12
+	var hues [hueBins]int
+	minDistance := math.Inf(1)
+	totalDistance := 0.0
+	lowContrast := 0
+	outputF.WriteString("body_l,body_c,body_h,hair_l,hair_c,hair_h,oklab_distance,delta_e_2000\n")
+	for i := 0; i < samples; i++ {
+		address := utils.GenerateAddress()
+		sha256 := utils.PKSha256(utils.AddressToPub(address), seed)
+		accessories, err := image.GetAccessoriesWith(sha256, gen, spc.BTNone, false, nil)
+		if err != nil {
+			continue
+		}
+		body := accessories.BodyColor.ToOKLCH()
+		hair := accessories.HairColor.ToOKLCH()
+		distance := body.ToOKLab().Distance(hair.ToOKLab())
+		outputF.WriteString(fmt.Sprintf("%f,%f,%f,%f,%f,%f,%f,%f\n", body.L, body.C, body.H, hair.L, hair.C, hair.H, distance, accessories.BodyColor.DeltaE2000(accessories.HairColor)))
+		hues[int(body.H/360*hueBins)%hueBins]++
+		minDistance = math.Min(minDistance, distance)
+		totalDistance += distance
+		if distance < image.MinHairLightnessDelta {
+			lowContrast++
+		}
+	}
+	for i, count := range hues {
+		print(fmt.Sprintf("H %d-%d %d\n", i*360/hueBins, (i+1)*360/hueBins, count))
+	}
+	print(fmt.Sprintf("Body/hair OKLab distance min %f mean %f\n", minDistance, totalDistance/samples))
+	print(fmt.Sprintf("Body/hair closer than %g: %d\n", image.MinHairLightnessDelta, lowContrast))
+}
@@ -0,0 +1,125 @@
+package controller
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/paw-digital/Pawnimals/server/db"
+)
+
+type fakeStatsWriter struct {
+	mu      sync.Mutex
+	batches []int
+	err     error
+}
+
+func (f *fakeStatsWriter) UpdateStatsBatch(events []db.StatsEvent) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.batches = append(f.batches, len(events))
+	return f.err
+}
+
+func statsEvent(i int) db.StatsEvent {
+	return db.StatsEvent{Address: "nano_1", ClientIP: "203.0.113.9", At: time.Unix(int64(i), 0)}
+}
+
+func TestStatsQueueFull(t *testing.T) {
+	// No worker, so nothing drains the queue
+	q := NewStatsQueue(&fakeStatsWriter{})
+	for i := 0; i < statsQueueSize; i++ {
+		if !q.Enqueue(statsEvent(i)) {
+			t.Fatalf("Expected event %d to be queued", i)
+		}
+	}
+	queued := make(chan bool)
+	go func() { queued <- q.Enqueue(statsEvent(statsQueueSize)) }()
+	select {
+	case ok := <-queued:
+		if ok {
+			t.Errorf("Expected a full queue to drop the event")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expected Enqueue not to block on a full queue")
+	}
+	if q.Dropped() != 1 {
+		t.Errorf("Expected 1 dropped event but got %d", q.Dropped())
+	}
+}
+
+func TestStatsQueueBatches(t *testing.T) {
+	writer := &fakeStatsWriter{}
+	q := NewStatsQueue(writer)
+	go q.StatsWorker()
+	for i := 0; i < 2*statsBatchSize+50; i++ {
+		q.Enqueue(statsEvent(i))
+	}
+	q.Close()
+	expected := []int{statsBatchSize, statsBatchSize, 50}
+	if len(writer.batches) != len(expected) {
+		t.Fatalf("Expected batches %v but got %v", expected, writer.batches)
+	}
+	for i, n := range expected {
+		if writer.batches[i] != n {
+			t.Errorf("Expected batches %v but got %v", expected, writer.batches)
+		}
+	}
+	if q.Dropped() != 0 || q.Failed() != 0 {
+		t.Errorf("Expected nothing lost but got %d dropped and %d failed", q.Dropped(), q.Failed())
+	}
+}
+
+func TestStatsQueueFlushesOnTick(t *testing.T) {
+	writer := &fakeStatsWriter{}
+	q := NewStatsQueue(writer)
+	go q.StatsWorker()
+	defer q.Close()
+	q.Enqueue(statsEvent(0))
+	time.Sleep(statsFlushInterval + statsFlushInterval/2)
+	writer.mu.Lock()
+	defer writer.mu.Unlock()
+	if len(writer.batches) != 1 || writer.batches[0] != 1 {
+		t.Errorf("Expected a batch of 1 after the flush interval but got %v", writer.batches)
+	}
+}
+
+func TestStatsQueueFailedWrites(t *testing.T) {
+	q := NewStatsQueue(&fakeStatsWriter{err: errors.New("redis down")})
+	go q.StatsWorker()
+	for i := 0; i < 10; i++ {
+		q.Enqueue(statsEvent(i))
+	}
+	q.Close()
+	if q.Failed() != 10 {
+		t.Errorf("Expected 10 failed events but got %d", q.Failed())
+	}
+}
+
+func TestStatsQueueEnqueueAfterClose(t *testing.T) {
+	q := NewStatsQueue(&fakeStatsWriter{})
+	go q.StatsWorker()
+	// Handlers still running during shutdown enqueue while the queue closes
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				q.Enqueue(statsEvent(i*100 + j))
+			}
+		}(i)
+	}
+	q.Close()
+	wg.Wait()
+	dropped := q.Dropped()
+	if q.Enqueue(statsEvent(0)) {
+		t.Errorf("Expected a closed queue to drop the event")
+	}
+	if q.Dropped() != dropped+1 {
+		t.Errorf("Expected the event to count as dropped")
+	}
+	// Closing twice is harmless
+	q.Close()
+}
@@ -0,0 +1,29 @@
+package controller
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/paw-digital/Pawnimals/server/db"
+	"github.com/paw-digital/Pawnimals/server/image"
+	"github.com/paw-digital/Pawnimals/server/utils"
+)
+
+// RenderTerminal - write the natricon for address to w as ANSI art width columns wide, for the -render command
+func RenderTerminal(w io.Writer, seed string, gen image.Generation, address string, width int, drawStyle image.Style) error {
+	if !utils.ValidateAddress(address) {
+		return fmt.Errorf("Invalid address %s", address)
+	}
+	nc := NatriconController{Seed: seed, Generation: gen}
+	style := iconStyle{drawStyle: drawStyle}
+	svg, err := style.render(nc.resolveAddress(address, db.NoNonceApplied, gen), "ansi", width, address)
+	if err != nil {
+		return err
+	}
+	ansi, err := rasterize(svg, "ansi", width)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(ansi)
+	return err
+}
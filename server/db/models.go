@@ -1,8 +1,35 @@
 package db
 
-import "time"
+import (
+	"time"
+
+	"github.com/paw-digital/Pawnimals/server/spc"
+)
 
 type Donor struct {
 	PubKey    string    `json:"pubkey"`
 	ExpiresAt time.Time `json:"expires_at"`
 }
+
+// Rep - a principal representative and the node badge tier it earned
+type Rep struct {
+	PubKey  string        `json:"pubkey"`
+	Account string        `json:"account"`
+	Weight  float64       `json:"weight"`
+	Badge   spc.BadgeType `json:"badge"`
+}
+
+// RepStatusChange - a rep gaining or losing principal status
+type RepStatusChange struct {
+	Principal bool      `json:"principal"`
+	Weight    float64   `json:"weight"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// StatsEvent - a natricon served to a client, captured when the request is handled
+type StatsEvent struct {
+	Address  string
+	ClientIP string
+	Service  string
+	At       time.Time
+}
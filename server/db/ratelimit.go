@@ -0,0 +1,75 @@
+package db
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+)
+
+// Refills the bucket for the time elapsed since it was last touched, then takes cost tokens if there are enough
+// Buckets expire once they would have refilled completely
+var takeTokensScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local cost = tonumber(ARGV[3])
+local now = tonumber(ARGV[4])
+local bucket = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(bucket[1])
+local ts = tonumber(bucket[2])
+if tokens == nil or ts == nil then
+	tokens = burst
+	ts = now
+end
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate / 1000)
+local allowed = 0
+if tokens >= cost then
+	tokens = tokens - cost
+	allowed = 1
+end
+redis.call("HMSET", KEYS[1], "tokens", tostring(tokens), "ts", now)
+redis.call("PEXPIRE", KEYS[1], math.ceil((burst - tokens) / rate * 1000) + 1000)
+return {allowed, tostring(tokens)}
+`)
+
+// RateLimitResult - outcome of taking tokens from a bucket
+type RateLimitResult struct {
+	Allowed    bool
+	Remaining  int           // Whole tokens left in the bucket
+	RetryAfter time.Duration // How long until the request would be allowed, 0 if it was
+	Reset      time.Duration // How long until the bucket is full again
+}
+
+// TakeTokens - take cost tokens from the bucket, which holds burst tokens and refills at rate per second
+func (r *redisManager) TakeTokens(bucket string, rate float64, burst int, cost int) (RateLimitResult, error) {
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	res, err := takeTokensScript.Run(r.Client, []string{fmt.Sprintf("%s:ratelimit:%s", keyPrefix, bucket)}, rate, burst, cost, now).Result()
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+	vals, ok := res.([]interface{})
+	if !ok || len(vals) != 2 {
+		return RateLimitResult{}, fmt.Errorf("unexpected rate limit response %v", res)
+	}
+	allowed, _ := vals[0].(int64)
+	tokensStr, _ := vals[1].(string)
+	tokens, err := strconv.ParseFloat(tokensStr, 64)
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+	result := RateLimitResult{
+		Allowed:   allowed == 1,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     secondsToDuration((float64(burst) - tokens) / rate),
+	}
+	if !result.Allowed {
+		result.RetryAfter = secondsToDuration((float64(cost) - tokens) / rate)
+	}
+	return result, nil
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(math.Ceil(seconds * float64(time.Second)))
+}
@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"strconv"
-	"strings"
 	"sync"
 	"time"
 
@@ -27,20 +26,23 @@ type redisManager struct {
 var singleton *redisManager
 var once sync.Once
 
+// Connection settings, set with Configure before the first GetDB
+var redisOptions = &redis.Options{
+	Addr: "localhost:6379",
+	DB:   0,
+}
+
+// Configure - set where to connect, has no effect once GetDB has been called
+func Configure(host string, port int, db int) {
+	redisOptions = &redis.Options{
+		Addr: fmt.Sprintf("%s:%d", host, port),
+		DB:   db,
+	}
+}
+
 func GetDB() *redisManager {
 	once.Do(func() {
-		redis_port, err := strconv.Atoi(utils.GetEnv("REDIS_PORT", "6379"))
-		if err != nil {
-			panic("Invalid REDIS_PORT specified")
-		}
-		redis_db, err := strconv.Atoi(utils.GetEnv("REDIS_DB", "0"))
-		if err != nil {
-			panic("Invalid REDIS_DB specified")
-		}
-		client := redis.NewClient(&redis.Options{
-			Addr: fmt.Sprintf("%s:%d", utils.GetEnv("REDIS_HOST", "localhost"), redis_port),
-			DB:   redis_db,
-		})
+		client := redis.NewClient(redisOptions)
 		// Create locker
 		// Create object
 		singleton = &redisManager{
@@ -51,6 +53,11 @@ func GetDB() *redisManager {
 	return singleton
 }
 
+// Ping - check that redis is reachable
+func (r *redisManager) Ping() error {
+	return r.Client.Ping().Err()
+}
+
 // del - Redis DEL
 func (r *redisManager) del(key string) (int64, error) {
 	val, err := r.Client.Del(key).Result()
@@ -189,7 +196,7 @@ func (r *redisManager) GetPrincipalRepRequirement() float64 {
 }
 
 // SetPrincipalReps - Cache principal reps
-func (r *redisManager) SetPrincipalReps(reps []string) {
+func (r *redisManager) SetPrincipalReps(reps []Rep) {
 	key := fmt.Sprintf("%s:principal_reps", keyPrefix)
 	marshalled, err := json.Marshal(reps)
 	if err == nil {
@@ -200,267 +207,128 @@ func (r *redisManager) SetPrincipalReps(reps []string) {
 }
 
 // GetPrincipalReps - Get cached principal reps
-func (r *redisManager) GetPrincipalReps() []string {
+func (r *redisManager) GetPrincipalReps() []Rep {
 	key := fmt.Sprintf("%s:principal_reps", keyPrefix)
 	reps, err := r.get(key)
 	if err != nil {
 		// Return empty set
-		return []string{}
-	}
-	var repsU []string
-	err = json.Unmarshal([]byte(reps), &repsU)
-	if err != nil {
-		return []string{}
+		return []Rep{}
 	}
-	return repsU
+	return decodePrincipalReps(reps)
 }
 
-// UpdateStatsAddress - Update stats for an address that has requested natricon
-func (r *redisManager) UpdateStatsAddress(address string) {
-	key := fmt.Sprintf("%s:stats_unique_addresses", keyPrefix)
-	count := 1
-	existing, err := r.hget(key, address)
-	if err == nil {
-		existingInt, err := strconv.Atoi(existing)
-		if err != nil {
-			count = existingInt + 1
-		}
-	}
-	err = r.hset(key, address, string(count))
-	if err != nil {
-		glog.Errorf("Error updating StatesAddresses %s", err)
-	}
-	key = fmt.Sprintf("%s:stats_total", keyPrefix)
-	val, err := r.get(key)
+// decodePrincipalReps - cached principal reps, empty if the value can't be read
+func decodePrincipalReps(reps string) []Rep {
+	var repsU []Rep
+	err := json.Unmarshal([]byte(reps), &repsU)
 	if err != nil {
-		glog.Errorf("Error updating StatesAddresses %s", err)
-		return
-	}
-	valInt, err := strconv.Atoi(val)
-	if err != nil {
-		glog.Errorf("Error updating StatesAddresses %s", err)
-	}
-	valInt += 1
-	r.set(key, strconv.Itoa(valInt))
-}
-
-// UpdateStatsDate - Update stats for current date
-func (r *redisManager) UpdateStatsDate(address string) {
-	dateStr := time.Now().Format("02-01-2006")
-	key := fmt.Sprintf("%s:stats_daily", keyPrefix)
-	existing, err := r.hget(key, fmt.Sprintf("%s_%s", dateStr, address))
-	count := 1
-	if err == nil {
-		existingInt, err := strconv.Atoi(existing)
-		if err != nil {
-			count = existingInt + 1
+		// Cache from before tiers existed was a flat list of public keys, drop what the failed decode left behind
+		var pubkeys []string
+		if err = json.Unmarshal([]byte(reps), &pubkeys); err != nil {
+			return []Rep{}
 		}
-	}
-	err = r.hset(key, fmt.Sprintf("%s_%s", dateStr, address), string(count))
-	if err != nil {
-		glog.Errorf("Error updating StatsDate %s", err)
-	}
-	// Total
-	total, err := r.hget(key, fmt.Sprintf("%s_%s", dateStr, "total"))
-	totalInt, err := strconv.Atoi(total)
-	if err != nil {
-		r.hset(key, fmt.Sprintf("%s_%s", dateStr, "total"), "1")
-	} else {
-		totalInt += 1
-		r.hset(key, fmt.Sprintf("%s_%s", dateStr, "total"), strconv.Itoa(totalInt))
-	}
-}
-
-// TodayStats - Today Stats
-func (r *redisManager) TodayStats() map[string]int64 {
-	dateStr := time.Now().Format("02-01-2006")
-	ret := map[string]int64{}
-	key := fmt.Sprintf("%s:stats_daily", keyPrefix)
-	allVals, err := r.hgetall(key)
-	if err != nil {
-		return ret
-	}
-	uniqueTracker := map[string]int64{}
-	for key, val := range allVals {
-		dt := strings.Split(key, "_")[0]
-		if dt != dateStr {
-			continue
-		}
-		// Increase total
-		if strings.Split(key, "_")[1] == "total" {
-			asInt, err := strconv.Atoi(val)
-			if err != nil {
-				ret["total"] = 1
-			} else {
-				ret["total"] = int64(asInt)
-			}
-		} else {
-			// Check and increase unique
-			if _, ok := uniqueTracker[key]; !ok {
-				uniqueTracker[key] = 1
-				if _, ok := ret["unique"]; !ok {
-					ret["unique"] = 1
-				} else {
-					ret["unique"] += 1
-				}
-			}
+		repsU = []Rep{}
+		for _, pk := range pubkeys {
+			repsU = append(repsU, Rep{PubKey: pk, Badge: spc.BTNode})
 		}
 	}
-	return ret
+	return repsU
 }
 
-// DailyStats - Daily Stats
-func (r *redisManager) DailyStats() map[string]map[string]int64 {
-	ret := map[string]map[string]int64{}
-	key := fmt.Sprintf("%s:stats_daily", keyPrefix)
-	allVals, err := r.hgetall(key)
-	if err != nil {
-		return ret
-	}
-	uniqueTracker := map[string]int64{}
-	for key, val := range allVals {
-		dt := strings.Split(key, "_")[0]
-		if _, ok := ret[dt]; !ok {
-			ret[dt] = map[string]int64{}
-		}
-		// Increase total
-		if strings.Split(key, "_")[1] == "total" {
-			asInt, err := strconv.Atoi(val)
-			if err != nil {
-				ret[dt]["total"] = 1
-			} else {
-				ret[dt]["total"] = int64(asInt)
-			}
-		} else {
-			// Check and increase unique
-			if _, ok := uniqueTracker[key]; !ok {
-				uniqueTracker[key] = 1
-				if _, ok := ret[dt]["unique"]; !ok {
-					ret[dt]["unique"] = 1
-				} else {
-					ret[dt]["unique"] += 1
-				}
-			}
+// SetRepWeights - Replace stored voting weight (in nano) of every representative
+func (r *redisManager) SetRepWeights(weights map[string]float64) error {
+	key := fmt.Sprintf("%s:rep_weights", keyPrefix)
+	values := make([]interface{}, 0, len(weights)*2)
+	for pubkey, weight := range weights {
+		values = append(values, pubkey, fmt.Sprintf("%f", weight))
+	}
+	_, err := r.Client.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.Del(key)
+		if len(values) > 0 {
+			pipe.HSet(key, values...)
 		}
-	}
-	return ret
+		return nil
+	})
+	return err
 }
 
-// UpdateStatsClient - Update stats for specific client
-func (r *redisManager) UpdateStatsClient(ip string) {
-	// Hash IP for privacy concerns
-	hashed := utils.PKSha256(ip, "")
-	key := fmt.Sprintf("%s:stats_clients", keyPrefix)
-	existing, err := r.hget(key, hashed)
-	count := 1
-	if err == nil {
-		existingInt, err := strconv.Atoi(existing)
-		if err != nil {
-			count = existingInt + 1
-		}
-	}
-	err = r.hset(key, hashed, string(count))
+// GetRepWeight - Get stored voting weight of a representative, returns false if unknown
+func (r *redisManager) GetRepWeight(pubkey string) (float64, bool) {
+	key := fmt.Sprintf("%s:rep_weights", keyPrefix)
+	weight, err := r.hget(key, pubkey)
 	if err != nil {
-		glog.Errorf("Error updating StatsClient %s", err)
+		return 0, false
 	}
-}
-
-// ClientsServed - return # of clients served
-func (r *redisManager) ClientsServed() int64 {
-	key := fmt.Sprintf("%s:stats_clients", keyPrefix)
-	len, err := r.hlen(key)
+	converted, err := strconv.ParseFloat(weight, 64)
 	if err != nil {
-		return 0
+		return 0, false
 	}
-	return len
+	return converted, true
 }
 
-// StatsUniqueAddresses - Return # of unique natricons served
-func (r *redisManager) StatsUniqueAddresses() int64 {
-	key := fmt.Sprintf("%s:stats_unique_addresses", keyPrefix)
-	len, err := r.hlen(key)
-	if err != nil {
-		return 0
-	}
-	return len
-}
+// Number of principal status changes kept per representative
+const maxRepStatusHistory = 100
 
-// StatsTotal - Return total # of unique natricons served
-func (r *redisManager) StatsTotal() int {
-	key := fmt.Sprintf("%s:stats_total", keyPrefix)
-	val, err := r.get(key)
+// AddRepStatusChange - Record a representative gaining or losing principal status
+func (r *redisManager) AddRepStatusChange(pubkey string, change RepStatusChange) {
+	key := fmt.Sprintf("%s:rep_history:%s", keyPrefix, pubkey)
+	marshalled, err := json.Marshal(change)
 	if err != nil {
-		return 0
+		glog.Errorf("Couldn't serialize rep status change %s", err)
+		return
 	}
-	valInt, err := strconv.Atoi(val)
+	_, err = r.Client.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.LPush(key, string(marshalled))
+		pipe.LTrim(key, 0, maxRepStatusHistory-1)
+		return nil
+	})
 	if err != nil {
-		return 0
+		glog.Errorf("Error saving rep status change for %s %s", pubkey, err)
 	}
-	return valInt
 }
 
-// UpdateStatsByService - Update stats for a service
-func (r *redisManager) UpdateStatsByService(svc string, address string) {
-	// See if valid service
-	valid := false
-	for _, rSvc := range spc.SvcList {
-		if string(rSvc) == svc {
-			valid = true
-		}
+// GetRepStatusHistory - Get principal status changes of a representative, newest first
+func (r *redisManager) GetRepStatusHistory(pubkey string) []RepStatusChange {
+	key := fmt.Sprintf("%s:rep_history:%s", keyPrefix, pubkey)
+	ret := []RepStatusChange{}
+	raw, err := r.Client.LRange(key, 0, -1).Result()
+	if err != nil {
+		return ret
 	}
-	if valid {
-		key := fmt.Sprintf("%s:stats:%s", keyPrefix, svc)
-		count := 1
-		existing, err := r.hget(key, address)
-		if err == nil {
-			existingInt, err := strconv.Atoi(existing)
-			if err != nil {
-				count = existingInt + 1
-			}
-		}
-		err = r.hset(key, address, strconv.Itoa(count))
-		if err != nil {
-			glog.Errorf("Error updating StatsByService %s %s", svc, err)
-		}
-		totalCount, err := r.hget(key, "total")
-		totalCountInt, err := strconv.Atoi(totalCount)
-		if err != nil {
-			totalCountInt = 0
-			allAddresses, err := r.hgetall(key)
-			if err == nil {
-				for _, el := range allAddresses {
-					indyInt, err := strconv.Atoi(el)
-					if err != nil {
-						totalCountInt += indyInt
-					}
-				}
-				r.hset(key, "total", strconv.Itoa(totalCountInt))
-			} else {
-				glog.Errorf("Error retrieving StatsBySvc %s %s", key, err)
-			}
-		} else {
-			r.hset(key, "total", strconv.Itoa(totalCountInt+1))
+	for _, item := range raw {
+		var change RepStatusChange
+		if err := json.Unmarshal([]byte(item), &change); err != nil {
+			glog.Errorf("Error unmarshalling rep status change %s", err)
+			continue
 		}
+		ret = append(ret, change)
 	}
+	return ret
 }
 
-// ServiceStats - Service Stats
-func (r *redisManager) ServiceStats() map[spc.StatsService]map[string]int64 {
-	ret := map[spc.StatsService]map[string]int64{}
-	for _, svc := range spc.SvcList {
-		key := fmt.Sprintf("%s:stats:%s", keyPrefix, svc)
-		len, err := r.hlen(key)
-		ret[svc] = map[string]int64{}
-		if err != nil {
-			ret[svc]["unique"] = 0
+// GetLatestRepStatusChanges - Get most recent principal status change of each given representative
+func (r *redisManager) GetLatestRepStatusChanges(pubkeys []string) map[string]RepStatusChange {
+	ret := map[string]RepStatusChange{}
+	cmds := make([]*redis.StringCmd, len(pubkeys))
+	_, err := r.Client.Pipelined(func(pipe redis.Pipeliner) error {
+		for i, pubkey := range pubkeys {
+			cmds[i] = pipe.LIndex(fmt.Sprintf("%s:rep_history:%s", keyPrefix, pubkey), 0)
 		}
-		ret[svc]["unique"] = len
-		totalCount, err := r.hget(key, "total")
-		totalCountInt, err := strconv.Atoi(totalCount)
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		glog.Errorf("Error retrieving rep status changes %s", err)
+		return ret
+	}
+	for i, cmd := range cmds {
+		raw, err := cmd.Result()
 		if err != nil {
-			totalCountInt = 0
+			continue
+		}
+		var change RepStatusChange
+		if err := json.Unmarshal([]byte(raw), &change); err == nil {
+			ret[pubkeys[i]] = change
 		}
-		ret[svc]["total"] = int64(totalCountInt)
 	}
 	return ret
 }
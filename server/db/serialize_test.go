@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"testing"
 	"time"
+
+	"github.com/paw-digital/Pawnimals/server/spc"
 )
 
 func TestSerializeDonor(t *testing.T) {
@@ -30,3 +32,28 @@ func TestUnSerializeDonor(t *testing.T) {
 		t.Error("Bad date after unmarshal")
 	}
 }
+
+func TestDecodePrincipalReps(t *testing.T) {
+	tests := []struct {
+		cached   string
+		expected []Rep
+	}{
+		{`[{"pubkey":"1234","account":"nano_1234","weight":5.5,"badge":"node_top"}]`, []Rep{{PubKey: "1234", Account: "nano_1234", Weight: 5.5, Badge: spc.BTNodeTop}}},
+		// Cached before tiers existed, every rep was a plain node
+		{`["1234","5678"]`, []Rep{{PubKey: "1234", Badge: spc.BTNode}, {PubKey: "5678", Badge: spc.BTNode}}},
+		{`[]`, []Rep{}},
+		{`garbage`, []Rep{}},
+	}
+	for _, test := range tests {
+		reps := decodePrincipalReps(test.cached)
+		if len(reps) != len(test.expected) {
+			t.Errorf("Expected %v but got %v for %s", test.expected, reps, test.cached)
+			continue
+		}
+		for i := range reps {
+			if reps[i] != test.expected[i] {
+				t.Errorf("Expected %v but got %v for %s", test.expected[i], reps[i], test.cached)
+			}
+		}
+	}
+}
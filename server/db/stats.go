@@ -0,0 +1,352 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/paw-digital/Pawnimals/server/spc"
+	"github.com/paw-digital/Pawnimals/server/utils"
+	"github.com/go-redis/redis/v7"
+	"github.com/golang/glog"
+)
+
+// Stats are kept as plain counters for totals and HyperLogLogs for uniques,
+// bucketed per day and per hour so ranges can be queried without scanning.
+const dayBucketFormat = "2006-01-02"
+const hourBucketFormat = "2006-01-02T15"
+
+// How long time-series buckets are kept around
+const dailyStatsRetention = 400 * 24 * time.Hour
+const hourlyStatsRetention = 14 * 24 * time.Hour
+
+// StatsInterval - granularity of a stats history query
+type StatsInterval string
+
+const (
+	StatsDaily  StatsInterval = "day"
+	StatsHourly StatsInterval = "hour"
+)
+
+// StatsBucket - served counts for a single day or hour
+type StatsBucket struct {
+	Bucket string `json:"bucket"`
+	Total  int64  `json:"total"`
+	Unique int64  `json:"unique,omitempty"`
+}
+
+// statsKey - build a stats key out of its parts
+func statsKey(parts ...string) string {
+	return fmt.Sprintf("%s:stats:%s", keyPrefix, strings.Join(parts, ":"))
+}
+
+// validService - whether svc is registered for stats
+func validService(svc string) bool {
+	for _, rSvc := range spc.SvcList {
+		if string(rSvc) == svc {
+			return true
+		}
+	}
+	return false
+}
+
+// UpdateStatsBatch - Record a batch of served natricons
+// The batch is applied in a single MULTI/EXEC, so it either counts fully or not at all.
+// Callers should not retry a failed batch, a retry after a timed out EXEC could count twice.
+func (r *redisManager) UpdateStatsBatch(events []StatsEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	_, err := r.Client.TxPipelined(func(pipe redis.Pipeliner) error {
+		for _, e := range events {
+			recordStats(pipe, e.Address, e.ClientIP, e.Service, e.At.UTC())
+		}
+		return nil
+	})
+	return err
+}
+
+// recordStats - queue all counter updates for one served natricon on pipe
+func recordStats(pipe redis.Pipeliner, address string, ip string, svc string, at time.Time) {
+	day := at.Format(dayBucketFormat)
+	hour := at.Format(hourBucketFormat)
+	// Hash IP for privacy concerns
+	client := utils.PKSha256(ip, "")
+
+	pipe.Incr(fmt.Sprintf("%s:stats_total", keyPrefix))
+	pipe.PFAdd(statsKey("addresses"), address)
+	pipe.PFAdd(statsKey("clients"), client)
+	incrExpire(pipe, statsKey("day", day, "total"), dailyStatsRetention)
+	pfAddExpire(pipe, statsKey("day", day, "addresses"), address, dailyStatsRetention)
+	pfAddExpire(pipe, statsKey("day", day, "clients"), client, dailyStatsRetention)
+	incrExpire(pipe, statsKey("hour", hour, "total"), hourlyStatsRetention)
+
+	if svc == "" || !validService(svc) {
+		return
+	}
+	pipe.Incr(statsKey("svc", svc, "total"))
+	pipe.PFAdd(statsKey("svc", svc, "addresses"), address)
+	incrExpire(pipe, statsKey("day", day, "svc", svc, "total"), dailyStatsRetention)
+	pfAddExpire(pipe, statsKey("day", day, "svc", svc, "addresses"), address, dailyStatsRetention)
+	incrExpire(pipe, statsKey("hour", hour, "svc", svc, "total"), hourlyStatsRetention)
+}
+
+func incrExpire(pipe redis.Pipeliner, key string, retention time.Duration) {
+	pipe.Incr(key)
+	pipe.Expire(key, retention)
+}
+
+func pfAddExpire(pipe redis.Pipeliner, key string, value string, retention time.Duration) {
+	pipe.PFAdd(key, value)
+	pipe.Expire(key, retention)
+}
+
+// getInt - GET a counter, 0 if missing
+func (r *redisManager) getInt(key string) int64 {
+	val, err := r.Client.Get(key).Int64()
+	if err != nil {
+		return 0
+	}
+	return val
+}
+
+// pfCount - PFCOUNT a HyperLogLog, 0 if missing
+func (r *redisManager) pfCount(key string) int64 {
+	val, err := r.Client.PFCount(key).Result()
+	if err != nil {
+		return 0
+	}
+	return val
+}
+
+// StatsUniqueAddresses - Return # of unique natricons served
+func (r *redisManager) StatsUniqueAddresses() int64 {
+	return r.pfCount(statsKey("addresses"))
+}
+
+// ClientsServed - return # of clients served
+func (r *redisManager) ClientsServed() int64 {
+	return r.pfCount(statsKey("clients"))
+}
+
+// StatsTotal - Return total # of natricons served
+func (r *redisManager) StatsTotal() int64 {
+	return r.getInt(fmt.Sprintf("%s:stats_total", keyPrefix))
+}
+
+// TodayStats - Today Stats
+func (r *redisManager) TodayStats() map[string]int64 {
+	day := time.Now().UTC().Format(dayBucketFormat)
+	return map[string]int64{
+		"total":  r.getInt(statsKey("day", day, "total")),
+		"unique": r.pfCount(statsKey("day", day, "addresses")),
+	}
+}
+
+// ServiceStats - Service Stats
+func (r *redisManager) ServiceStats() map[spc.StatsService]map[string]int64 {
+	ret := map[spc.StatsService]map[string]int64{}
+	for _, svc := range spc.SvcList {
+		ret[svc] = map[string]int64{
+			"unique": r.pfCount(statsKey("svc", string(svc), "addresses")),
+			"total":  r.getInt(statsKey("svc", string(svc), "total")),
+		}
+	}
+	return ret
+}
+
+// StatsBuckets - Bucket names covering from..to (inclusive) for the given interval
+func StatsBuckets(from time.Time, to time.Time, interval StatsInterval) []string {
+	var step time.Duration
+	var format string
+	if interval == StatsHourly {
+		step = time.Hour
+		format = hourBucketFormat
+		from = from.Truncate(time.Hour)
+	} else {
+		step = 24 * time.Hour
+		format = dayBucketFormat
+		from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+	}
+	ret := []string{}
+	for t := from; !t.After(to); t = t.Add(step) {
+		ret = append(ret, t.Format(format))
+	}
+	return ret
+}
+
+// StatsHistory - served counts per bucket, optionally for a single service
+func (r *redisManager) StatsHistory(from time.Time, to time.Time, interval StatsInterval, svc string) ([]StatsBucket, error) {
+	if svc != "" && !validService(svc) {
+		return nil, errors.New("Unknown service")
+	}
+	buckets := StatsBuckets(from, to, interval)
+	totals := make([]*redis.StringCmd, len(buckets))
+	uniques := make([]*redis.IntCmd, len(buckets))
+	_, err := r.Client.Pipelined(func(pipe redis.Pipeliner) error {
+		for i, bucket := range buckets {
+			parts := []string{string(interval), bucket}
+			if svc != "" {
+				parts = append(parts, "svc", svc)
+			}
+			totals[i] = pipe.Get(statsKey(append(parts, "total")...))
+			// Uniques are only tracked per day
+			if interval == StatsDaily {
+				uniques[i] = pipe.PFCount(statsKey(append(parts, "addresses")...))
+			}
+		}
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+	ret := make([]StatsBucket, len(buckets))
+	for i, bucket := range buckets {
+		ret[i].Bucket = bucket
+		ret[i].Total, _ = totals[i].Int64()
+		if uniques[i] != nil {
+			ret[i].Unique = uniques[i].Val()
+		}
+	}
+	return ret, nil
+}
+
+// Legacy stats keys, hashes that grew with every address and client
+const legacyDayFormat = "02-01-2006"
+
+var legacyStatsKeys = []string{
+	fmt.Sprintf("%s:stats_unique_addresses", keyPrefix),
+	fmt.Sprintf("%s:stats_clients", keyPrefix),
+	fmt.Sprintf("%s:stats_daily", keyPrefix),
+}
+
+// MigrateStats - Move stats from the legacy hashes into HyperLogLogs and daily buckets, runs once
+func (r *redisManager) MigrateStats() {
+	doneKey := statsKey("migrated")
+	if r.getInt(doneKey) == 1 {
+		return
+	}
+	lock, err := r.Locker.Obtain(fmt.Sprintf("%s:stats_migration_lock", keyPrefix), 10*time.Minute, nil)
+	if err != nil {
+		return
+	}
+	defer lock.Release()
+	// Another instance may have finished while we waited
+	if r.getInt(doneKey) == 1 {
+		return
+	}
+	glog.Infof("Migrating legacy stats")
+	migrated := append([]string{}, legacyStatsKeys...)
+	// HyperLogLogs are written page by page since adding twice changes nothing, totals are
+	// summed here and only written together with doneKey so a failed run can't count them twice
+	dailyTotals := map[string]int64{}
+	svcTotals := map[spc.StatsService]int64{}
+
+	err = r.hscanAll(legacyStatsKeys[0], func(pipe redis.Pipeliner, field string, value string) {
+		pipe.PFAdd(statsKey("addresses"), field)
+	})
+	if err != nil {
+		glog.Errorf("Error migrating unique addresses %s", err)
+		return
+	}
+	// Clients were already hashed
+	err = r.hscanAll(legacyStatsKeys[1], func(pipe redis.Pipeliner, field string, value string) {
+		pipe.PFAdd(statsKey("clients"), field)
+	})
+	if err != nil {
+		glog.Errorf("Error migrating clients %s", err)
+		return
+	}
+	// Daily fields are <DD-MM-YYYY>_<address> or <DD-MM-YYYY>_total
+	err = r.hscanAll(legacyStatsKeys[2], func(pipe redis.Pipeliner, field string, value string) {
+		split := strings.SplitN(field, "_", 2)
+		if len(split) != 2 {
+			return
+		}
+		date, err := time.Parse(legacyDayFormat, split[0])
+		if err != nil || time.Since(date) > dailyStatsRetention {
+			return
+		}
+		day := date.Format(dayBucketFormat)
+		if split[1] == "total" {
+			total, err := strconv.ParseInt(value, 10, 64)
+			if err == nil {
+				dailyTotals[day] += total
+			}
+			return
+		}
+		pfAddExpire(pipe, statsKey("day", day, "addresses"), split[1], dailyStatsRetention)
+	})
+	if err != nil {
+		glog.Errorf("Error migrating daily stats %s", err)
+		return
+	}
+	// Per service hashes are <address> -> count plus a total field
+	for _, svc := range spc.SvcList {
+		legacyKey := fmt.Sprintf("%s:stats:%s", keyPrefix, svc)
+		err = r.hscanAll(legacyKey, func(pipe redis.Pipeliner, field string, value string) {
+			if field == "total" {
+				total, err := strconv.ParseInt(value, 10, 64)
+				if err == nil {
+					svcTotals[svc] += total
+				}
+				return
+			}
+			pipe.PFAdd(statsKey("svc", string(svc), "addresses"), field)
+		})
+		if err != nil {
+			glog.Errorf("Error migrating %s stats %s", svc, err)
+			return
+		}
+		migrated = append(migrated, legacyKey)
+	}
+
+	// Totals are added rather than set so natricons served since the deploy still count
+	_, err = r.Client.TxPipelined(func(pipe redis.Pipeliner) error {
+		for day, total := range dailyTotals {
+			incrByExpire(pipe, statsKey("day", day, "total"), total, dailyStatsRetention)
+		}
+		for svc, total := range svcTotals {
+			pipe.IncrBy(statsKey("svc", string(svc), "total"), total)
+		}
+		pipe.Set(doneKey, "1", 0)
+		return nil
+	})
+	if err != nil {
+		glog.Errorf("Error migrating stats totals %s", err)
+		return
+	}
+	r.Client.Del(migrated...)
+	glog.Infof("Finished migrating legacy stats")
+}
+
+func incrByExpire(pipe redis.Pipeliner, key string, value int64, retention time.Duration) {
+	pipe.IncrBy(key, value)
+	pipe.Expire(key, retention)
+}
+
+// hscanAll - HSCAN through key, queueing writes from fn in one pipeline per page
+func (r *redisManager) hscanAll(key string, fn func(pipe redis.Pipeliner, field string, value string)) error {
+	var cursor uint64
+	for {
+		page, next, err := r.Client.HScan(key, cursor, "", 1000).Result()
+		if err != nil {
+			return err
+		}
+		_, err = r.Client.Pipelined(func(pipe redis.Pipeliner) error {
+			for i := 0; i+1 < len(page); i += 2 {
+				fn(pipe, page[i], page[i+1])
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if next == 0 {
+			return nil
+		}
+		cursor = next
+	}
+}
@@ -0,0 +1,44 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStatsBucketsDaily(t *testing.T) {
+	from := time.Date(2020, 12, 30, 18, 0, 0, 0, time.UTC)
+	to := time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC)
+	buckets := StatsBuckets(from, to, StatsDaily)
+	expected := []string{"2020-12-30", "2020-12-31", "2021-01-01", "2021-01-02"}
+	if len(buckets) != len(expected) {
+		t.Fatalf("Expected %d buckets but got %d", len(expected), len(buckets))
+	}
+	for i := range expected {
+		if buckets[i] != expected[i] {
+			t.Errorf("Expected bucket %s but got %s", expected[i], buckets[i])
+		}
+	}
+}
+
+func TestStatsBucketsHourly(t *testing.T) {
+	from := time.Date(2020, 11, 19, 22, 30, 0, 0, time.UTC)
+	to := time.Date(2020, 11, 20, 1, 0, 0, 0, time.UTC)
+	buckets := StatsBuckets(from, to, StatsHourly)
+	expected := []string{"2020-11-19T22", "2020-11-19T23", "2020-11-20T00", "2020-11-20T01"}
+	if len(buckets) != len(expected) {
+		t.Fatalf("Expected %d buckets but got %d", len(expected), len(buckets))
+	}
+	for i := range expected {
+		if buckets[i] != expected[i] {
+			t.Errorf("Expected bucket %s but got %s", expected[i], buckets[i])
+		}
+	}
+}
+
+func TestStatsKey(t *testing.T) {
+	expected := "pawnimal:stats:day:2020-11-19:svc:natrium:total"
+	key := statsKey("day", "2020-11-19", "svc", "natrium", "total")
+	if key != expected {
+		t.Errorf("Expected %s but got %s", expected, key)
+	}
+}
@@ -22,6 +22,7 @@ require (
 	github.com/modern-go/concurrent v0.0.0-20180306012644-bacd9c7ef1dd // indirect
 	github.com/paw-digital/nano v0.0.0-20211111065128-af0e9f7f22dc
 	github.com/paw-digital/redislock v0.0.0-20211111082631-cbf7b6ab3f2e
+	github.com/prometheus/client_golang v1.11.0
 	github.com/recws-org/recws v1.3.1
 	github.com/tdewolff/minify/v2 v2.9.22
 	github.com/tdewolff/parse/v2 v2.5.22 // indirect
@@ -33,4 +34,5 @@ require (
 	google.golang.org/genproto v0.0.0-20200526211855-cb27e3aa2013 // indirect
 	google.golang.org/protobuf v1.27.1 // indirect
 	gopkg.in/gographics/imagick.v3 v3.4.0
+	gopkg.in/yaml.v2 v2.4.0
 )
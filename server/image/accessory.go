@@ -2,13 +2,11 @@ package image
 
 import (
 	"errors"
-	"fmt"
 	"regexp"
 	"strconv"
 	"strings"
 
 	"github.com/paw-digital/Pawnimals/server/color"
-	"github.com/paw-digital/Pawnimals/server/rand"
 	"github.com/paw-digital/Pawnimals/server/spc"
 )
 
@@ -25,7 +23,17 @@ type Accessories struct {
 	HairOutlineAsset  *Asset
 	MouthOutlineAsset *Asset
 	BadgeAsset        *Asset
+	BadgeType         spc.BadgeType
+	Outline           bool
 	OutlineColor      color.RGB
+	OutlineWidth      float64 // In canvas units, DefaultOutlineWidth if 0
+	Frame             Frame
+	IDPrefix          string   // If set, ids and references are namespaced with it for inlining
+	AltText           *AltText // If set, emitted as title and desc with role="img"
+	DisplaySize       int      // Pixels the icon is shown at, at or below LegibleSize it is simplified. 0 if unknown
+	Style             Style
+	CVD               color.Deficiency // If set, every color is shown as someone with this deficiency sees it
+	Legendary         Legendary        // Special effect of a tiny share of hashes, never set for vanities
 }
 
 // Hex string regex
@@ -49,12 +57,20 @@ func GetSpecificNatricon(badgeType spc.BadgeType, outline bool, outlineColor *co
 	// Get badge
 	if badgeType != "" && badgeType != spc.BTNone {
 		accessories.BadgeAsset = GetBadgeAsset(accessories.FaceAsset, badgeType)
+		accessories.BadgeType = badgeType
 	}
 
 	// Eyes and mouth
 	//accessories.MouthAsset = GetMouthAssetWithID(mouthAsset)
 	//accessories.EyeAsset = GetEyeAssetWithID(eyeAsset)
 
+	if outline {
+		accessories.Outline = true
+		if outlineColor != nil {
+			accessories.OutlineColor = *outlineColor
+		}
+	}
+
 	// Get outlines
 	/*if outline {
 		accessories.BodyOutlineAsset = GetBodyOutlineAsset(accessories.FaceAsset)
@@ -72,6 +88,11 @@ func GetSpecificNatricon(badgeType spc.BadgeType, outline bool, outlineColor *co
 
 // GetAccessoriesForHash - Return Accessories object based on 64-character hex string
 func GetAccessoriesForHash(hash string, badgeType spc.BadgeType, outline bool, outlineColor *color.RGB) (Accessories, error) {
+	return GetAccessoriesWith(hash, Generation{Scheme: DefaultScheme}, badgeType, outline, outlineColor)
+}
+
+// GetAccessoriesWith - GetAccessoriesForHash with colors picked by gen
+func GetAccessoriesWith(hash string, gen Generation, badgeType spc.BadgeType, outline bool, outlineColor *color.RGB) (Accessories, error) {
 	var err error
 	if len(hash) != 64 {
 		return Accessories{}, errors.New("Invalid hash")
@@ -83,39 +104,52 @@ func GetAccessoriesForHash(hash string, badgeType spc.BadgeType, outline bool, o
 
 	// Create empty Accessories object
 	var accessories = Accessories{}
-	// Body color uses first 12 digits of hash as seed
-	accessories.BodyColor, err = GetBodyColor(hash[0:16])
+	// Colors come from hash[0:34], the classic scheme seeds the body with the first 16 digits and the hair with the next 18
+	accessories.BodyColor, accessories.HairColor, err = gen.Colors(hash)
 	if err != nil {
 		return Accessories{}, err
 	}
 
-	//// Get hair color
-	accessories.HairColor, err = GetHairColor(accessories.BodyColor, hash[16:26], hash[26:30], hash[30:34])
-
 	// Get body and hair illustrations
-	accessories.FaceAsset, err = GetFaceAsset(hash[34:40])
-	//accessories.HairAsset, err = GetHairAsset(hash[40:46], &accessories.FaceAsset)
+	accessories.FaceAsset, err = GetFaceAsset(hash[34:40], gen.Scheme.WeightedTraits())
+	if err != nil {
+		return Accessories{}, err
+	}
+	if gen.Scheme.LegendaryVariants() {
+		accessories.Legendary, err = GetLegendary(hash)
+		if err != nil {
+			return Accessories{}, err
+		}
+	}
+
+	// Outline is drawn around the face by CombineSVG, black unless specified
+	if outline {
+		accessories.Outline = true
+		if outlineColor != nil {
+			accessories.OutlineColor = *outlineColor
+		}
+	}
+	//accessories.HairAsset, err = GetHairAsset(hash[40:46], &accessories.FaceAsset, gen.Scheme.WeightedTraits())
 	//accessories.BackHairAsset = GetBackHairAsset(accessories.HairAsset)
 
 	/*
 	// Get badge
 	if badgeType != "" && badgeType != spc.BTNone {
 		accessories.BadgeAsset = GetBadgeAsset(accessories.FaceAsset, badgeType)
+		accessories.BadgeType = badgeType
 	}*/
 	
 	/*
 	// Get mouth and eyes
-	targetSex := Neutral
-	if accessories.FaceAsset.Sex != Neutral {
-		targetSex = accessories.FaceAsset.Sex
-	} else if accessories.HairAsset.Sex != Neutral {
-		targetSex = accessories.HairAsset.Sex
+	targetSex := GetRules().Sex(Face, accessories.FaceAsset)
+	if targetSex == Neutral {
+		targetSex = GetRules().Sex(Hair, *accessories.HairAsset)
 	}
-	accessories.MouthAsset, err = GetMouthAsset(hash[46:55], targetSex, accessories.BodyColor.PerceivedBrightness())
-	if targetSex == Neutral && accessories.MouthAsset.Sex != Neutral {
-		targetSex = accessories.MouthAsset.Sex
+	accessories.MouthAsset, err = GetMouthAsset(hash[46:55], targetSex, accessories.BodyColor.PerceivedBrightness(), gen.Scheme.WeightedTraits())
+	if targetSex == Neutral {
+		targetSex = GetRules().Sex(Mouth, *accessories.MouthAsset)
 	}
-	accessories.EyeAsset, err = GetEyeAsset(hash[55:64], targetSex, accessories.BodyColor.PerceivedBrightness())
+	accessories.EyeAsset, err = GetEyeAsset(hash[55:64], targetSex, accessories.BodyColor.PerceivedBrightness(), gen.Scheme.WeightedTraits())
 	
 	// Get outlines
 	if outline {
@@ -129,21 +163,20 @@ func GetAccessoriesForHash(hash string, badgeType spc.BadgeType, outline bool, o
 		}
 	}
 	*/
+	if err := GetRules().Validate(accessories); err != nil {
+		return Accessories{}, err
+	}
 	return accessories, nil
 }
 
-// GetFaceAsset - return body illustration to use with given entropy
-func GetFaceAsset(entropy string) (Asset, error) {
-	// Get detemrinistic RNG
-	randSeed, err := strconv.ParseInt(entropy, 16, 64)
+// GetFaceAsset - return body illustration to use with given entropy, by manifest weight if weighted
+func GetFaceAsset(entropy string, weighted bool) (Asset, error) {
+	faceIndex, err := pickAsset(entropy, GetAssets().GetFaceAssets(), weighted)
 	if err != nil {
 		return Asset{}, err
+	} else if faceIndex < 0 {
+		return Asset{}, errors.New("No face assets")
 	}
-
-	r := rand.Init()
-	r.Seed(uint32(randSeed))
-	faceIndex := r.Int31n(int32(GetAssets().GetNFaceAssets()))
-
 	return GetAssets().GetFaceAssets()[faceIndex], nil
 }
 
@@ -166,44 +199,27 @@ func GetFaceAssetWithID(id int) Asset {
 
 // GetBodyOutlineAsset - return body outline illustration for a given body asset
 func GetBodyOutlineAsset(faceAsset Asset) *Asset {
-	for _, ba := range GetAssets().GetBodyOutlineAssets() {
-		if ba.FileName == faceAsset.FileName {
-			return &ba
-		}
-	}
-	return nil
+	return GetRules().Outline(Face, faceAsset, GetAssets().GetBodyOutlineAssets())
 }
 
-// GetBadgeAsset - return badge asset for a particular body
+// GetBadgeAsset - return badge asset that fits a particular body
 func GetBadgeAsset(bodyAsset Asset, btype spc.BadgeType) *Asset {
-	identifier, _ := strconv.Atoi(strings.Split(bodyAsset.FileName, "_")[0])
-	searchStr := fmt.Sprintf("b%d", identifier)
-	for _, v := range GetAssets().GetBadgeAssets(btype) {
-		if strings.Contains(v.FileName, searchStr) {
-			return &v
-		}
-	}
-	return nil
+	return GetRules().Badge(bodyAsset, GetAssets().GetBadgeAssets(btype))
 }
 
-// GetHairAsset - return hair illustration to use with given entropy
-func GetHairAsset(entropy string, bodyAsset *Asset) *Asset {
-	// Get detemrinistic RNG
-	randSeed, err := strconv.ParseInt(entropy, 16, 64)
-	if err != nil {
-		return nil
+// GetHairAsset - return hair illustration to use with given entropy, by manifest weight if weighted
+func GetHairAsset(entropy string, bodyAsset *Asset, weighted bool) *Asset {
+	var hairAssetOptions []Asset
+	for _, ha := range GetAssets().GetHairAssets(GetRules().Sex(Face, *bodyAsset)) {
+		if GetRules().Fits(Hair, ha, *bodyAsset) {
+			hairAssetOptions = append(hairAssetOptions, ha)
+		}
 	}
-
-	hairAssetOptions := GetAssets().GetHairAssets(bodyAsset.Sex)
-
-	r := rand.Init()
-	r.Seed(uint32(randSeed))
-	hairIndex := r.Int31n(int32(len(hairAssetOptions)))
-
-	if(len(hairAssetOptions) > 0) {
-		return &hairAssetOptions[hairIndex]
+	hairIndex, err := pickAsset(entropy, hairAssetOptions, weighted)
+	if err != nil || hairIndex < 0 {
+		return nil
 	}
-	return nil
+	return &hairAssetOptions[hairIndex]
 }
 
 // GetHairAssetWithID - return body illustration with given ID
@@ -235,32 +251,17 @@ func GetBackHairAsset(hairAsset Asset) *Asset {
 
 // GetHairOutlineAsset - return hair outline illustration for a given hair asset
 func GetHairOutlineAsset(hairAsset Asset) *Asset {
-	for _, ba := range GetAssets().GetHairOutlineAssets() {
-		if ba.FileName == hairAsset.FileName {
-			return &ba
-		}
-	}
-	return nil
+	return GetRules().Outline(Hair, hairAsset, GetAssets().GetHairOutlineAssets())
 }
 
-// GetEyeAsset - return hair illustration to use with given entropy
-func GetEyeAsset(entropy string, sex Sex, luminosity float64) *Asset {
-	// Get detemrinistic RNG
-	randSeed, err := strconv.ParseInt(entropy, 16, 64)
-	if err != nil {
-		return nil
-	}
-
+// GetEyeAsset - return eye illustration to use with given entropy, by manifest weight if weighted
+func GetEyeAsset(entropy string, sex Sex, luminosity float64, weighted bool) *Asset {
 	eyeAssetOptions := GetAssets().GetEyeAssets(sex, luminosity)
-
-	r := rand.Init()
-	r.Seed(uint32(randSeed))
-	eyeIndex := r.Int31n(int32(len(eyeAssetOptions)))
-
-	if(len(eyeAssetOptions) > 0) {
-		return &eyeAssetOptions[eyeIndex]
+	eyeIndex, err := pickAsset(entropy, eyeAssetOptions, weighted)
+	if err != nil || eyeIndex < 0 {
+		return nil
 	}
-	return nil
+	return &eyeAssetOptions[eyeIndex]
 }
 
 // GetEyeAssetWithID - return eye illustration with given ID
@@ -280,24 +281,14 @@ func GetEyeAssetWithID(id int) *Asset {
 	return &GetAssets().GetEyeAssets(Neutral, 100)[0]
 }
 
-// GetEyeAsset - return hair illustration to use with given entropy
-func GetMouthAsset(entropy string, sex Sex, luminosity float64) *Asset {
-	// Get detemrinistic RNG
-	randSeed, err := strconv.ParseInt(entropy, 16, 64)
-	if err != nil {
+// GetMouthAsset - return mouth illustration to use with given entropy, by manifest weight if weighted
+func GetMouthAsset(entropy string, sex Sex, luminosity float64, weighted bool) *Asset {
+	mouthAssetOptions := GetAssets().GetMouthAssets(sex, luminosity)
+	mouthIndex, err := pickAsset(entropy, mouthAssetOptions, weighted)
+	if err != nil || mouthIndex < 0 {
 		return nil
 	}
-
-	mouthAssetOptions := GetAssets().GetMouthAssets(sex, luminosity)
-
-	r := rand.Init()
-	r.Seed(uint32(randSeed))
-	mouthIndex := r.Int31n(int32(len(mouthAssetOptions)))
-
-	if(len(mouthAssetOptions) > 0) {
-		return &mouthAssetOptions[mouthIndex]
-	}	
-	return nil
+	return &mouthAssetOptions[mouthIndex]
 }
 
 // GetMouthAssetWithID - return mouth illustration with given ID
@@ -319,10 +310,5 @@ func GetMouthAssetWithID(id int) *Asset {
 
 // GetMouthOutlineAsset - return mouth outline illustration for a given mouth asset
 func GetMouthOutlineAsset(mouthAsset Asset) *Asset {
-	for _, ba := range GetAssets().GetMouthOutlineAssets() {
-		if ba.FileName == mouthAsset.FileName {
-			return &ba
-		}
-	}
-	return nil
+	return GetRules().Outline(Mouth, mouthAsset, GetAssets().GetMouthOutlineAssets())
 }
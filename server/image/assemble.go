@@ -10,6 +10,7 @@ import (
 
 	svg "github.com/ajstarks/svgo"
 	"github.com/paw-digital/Pawnimals/server/color"
+	"github.com/paw-digital/Pawnimals/server/spc"
 	"github.com/golang/glog"
 	minify "github.com/tdewolff/minify/v2"
 	minifysvg "github.com/tdewolff/minify/v2/svg"
@@ -17,6 +18,10 @@ import (
 
 const DefaultSize = 1080            // Default SVG width/height attribute
 const lodBwReplacement = "#9CA2AF" // Replace white with this color on bw assets
+const nodeBadgeColor = "#00997F"    // Fill of the node badge assets
+const topNodeBadgeColor = "#D4A017" // Node badge fill for the highest weighted reps
+const DefaultOutlineWidth = 16.0    // Outline width in canvas units
+const MaxOutlineWidth = 64.0        // Widest outline allowed
 
 type SVG struct {
 	Width  int    `xml:"width,attr"`
@@ -89,12 +94,55 @@ func CombineSVG(accessories Accessories) ([]byte, error) {
 			return nil, err
 		}
 	}
+	// Fine shading turns to mush when shown small
+	if accessories.DisplaySize > 0 && accessories.DisplaySize <= LegibleSize {
+		for _, asset := range []*SVG{&face, &hair, &mouth, &eye, &backHair, &bodyOutline, &hairOutline, &mouthOutline, &badgeAsset} {
+			asset.Doc = simplify(asset.Doc, accessories.DisplaySize)
+		}
+		accessories.Frame.Shadow = false
+		if min := minStrokeWidth(accessories.DisplaySize); accessories.Outline && accessories.OutlineWidth < min {
+			accessories.OutlineWidth = min
+		}
+	}
+	// Shading and shadows blend into colors outside the palette of the other styles
+	if accessories.Style != StyleFlat {
+		for _, asset := range []*SVG{&face, &hair, &mouth, &eye, &backHair, &badgeAsset} {
+			asset.Doc = dropShading(asset.Doc)
+		}
+		accessories.Frame.Shadow = false
+	}
+	// Gold outline of the golden variant in place of any other, mono keeps its black one
+	if accessories.Legendary == LegendaryGolden && accessories.Style != StyleMono {
+		accessories.Outline = true
+		accessories.OutlineColor = goldColor
+	}
+	// White on white paper needs a silhouette
+	if accessories.Style == StyleMono && !accessories.Outline {
+		accessories.Outline = true
+		accessories.OutlineColor = color.RGB{R: 0, G: 0, B: 0}
+	}
 	// Perceved brightness of body used for some manipulations
 	perceivedBrightness := int(accessories.BodyColor.PerceivedBrightness())
 	// Create new SVG writer
 	var b bytes.Buffer
 	canvas := svg.New(&b)
-	canvas.Startraw(fmt.Sprintf("viewBox=\"0 0 %d %d\"", DefaultSize, DefaultSize))
+	if accessories.AltText != nil {
+		canvas.Startraw(fmt.Sprintf("viewBox=\"0 0 %d %d\"", DefaultSize, DefaultSize), `role="img"`, `aria-labelledby="title desc"`)
+		writeAltText(canvas, *accessories.AltText)
+	} else {
+		canvas.Startraw(fmt.Sprintf("viewBox=\"0 0 %d %d\"", DefaultSize, DefaultSize))
+	}
+	// Background, shape, padding and shadow
+	writeFrameDefs(canvas, accessories.Frame)
+	frameGroups := startFrame(canvas, accessories.Frame)
+	writeStyleDefs(canvas, accessories.Style)
+	writeLegendaryDefs(canvas, accessories)
+	styleGroups := startStyle(canvas, accessories.Style)
+	// Outline everything but the badge, which carries its own ring in the outline color
+	if accessories.Outline {
+		writeOutlineFilter(canvas, accessories.OutlineColor, accessories.OutlineWidth)
+		canvas.Group(`filter="url(#outline)"`)
+	}
 	// Add body outline
 	if accessories.BodyOutlineAsset != nil {
 		canvas.Gid("bodyOutline")
@@ -131,7 +179,7 @@ func CombineSVG(accessories Accessories) ([]byte, error) {
 	if accessories.FaceAsset.BodyColored {
 		//face.Doc = strings.ReplaceAll(face.Doc, "#00FFFF", accessories.BodyColor.ToHTML(true))
 		//face.Doc = strings.ReplaceAll(face.Doc, "fill-opacity=\"0.15\"", fmt.Sprintf("fill-opacity=\"%f\"", GetTargetOpacity(accessories.BodyColor)))
-		face.Doc = strings.ReplaceAll(face.Doc, "#06c2b5", accessories.BodyColor.ToHTML(true))
+		face.Doc = strings.ReplaceAll(face.Doc, "#06c2b5", legendaryBodyFill(accessories))
 		face.Doc = strings.ReplaceAll(face.Doc, "fill-opacity=\"0.15\"", fmt.Sprintf("fill-opacity=\"%f\"", GetTargetOpacity(accessories.BodyColor)))
 		face.Doc = strings.ReplaceAll(face.Doc, "#6dfff9", accessories.HairColor.ToHTML(true))
 		face.Doc = strings.ReplaceAll(face.Doc, "fill-opacity=\"0.65\"", fmt.Sprintf("fill-opacity=\"%f\"", GetTargetOpacity(accessories.HairColor)))
@@ -183,26 +231,73 @@ func CombineSVG(accessories Accessories) ([]byte, error) {
 		io.WriteString(canvas.Writer, eye.Doc)
 		canvas.Gend()
 	}
+	if accessories.Outline {
+		canvas.Gend()
+	}
+	writeSparkles(canvas, accessories)
 	// Badge group
 	if accessories.BadgeAsset != nil {
-		canvas.Gid("badge")
+		if anchor := GetRules().BadgeAnchor(accessories.FaceAsset); anchor != nil {
+			canvas.Group(`id="badge"`, fmt.Sprintf(`transform="translate(%g %g)"`, anchor.X, anchor.Y))
+		} else {
+			canvas.Gid("badge")
+		}
 		// Change color based on outline
-		if accessories.BodyOutlineAsset != nil {
+		if accessories.Outline || accessories.BodyOutlineAsset != nil {
 			badgeAsset.Doc = strings.ReplaceAll(badgeAsset.Doc, "white", accessories.OutlineColor.ToHTML(true))
 		}
+		if accessories.BadgeType == spc.BTNodeTop {
+			badgeAsset.Doc = strings.ReplaceAll(badgeAsset.Doc, nodeBadgeColor, topNodeBadgeColor)
+		}
 		io.WriteString(canvas.Writer, badgeAsset.Doc)
 		canvas.Gend()
 	}
+	for i := 0; i < styleGroups+frameGroups; i++ {
+		canvas.Gend()
+	}
 	// End document
 	canvas.End()
 
 	// Minify
 	var ret []byte
-	ret, _ = getMinifier().minifier.Bytes("image/svg+xml", b.Bytes())
+	doc := applyStylePalette(b.Bytes(), accessories)
+	if accessories.CVD != "" {
+		doc = recolor(doc, func(c color.RGB) color.RGB {
+			return c.SimulateCVD(accessories.CVD)
+		})
+	}
+	ret, _ = getMinifier().minifier.Bytes("image/svg+xml", doc)
+	if accessories.IDPrefix != "" {
+		ret = NamespaceIDs(ret, accessories.IDPrefix)
+	}
 
 	return ret, nil
 }
 
+// writeAltText - title and desc, which have to be the first children of the svg
+func writeAltText(canvas *svg.SVG, alt AltText) {
+	io.WriteString(canvas.Writer, `<title id="title">`)
+	xml.EscapeText(canvas.Writer, []byte(alt.Title))
+	io.WriteString(canvas.Writer, `</title><desc id="desc">`)
+	xml.EscapeText(canvas.Writer, []byte(alt.Description))
+	io.WriteString(canvas.Writer, `</desc>`)
+}
+
+// writeOutlineFilter - define an "outline" filter that dilates the shape's alpha and fills it with outlineColor underneath
+func writeOutlineFilter(canvas *svg.SVG, outlineColor color.RGB, width float64) {
+	if width <= 0 {
+		width = DefaultOutlineWidth
+	}
+	canvas.Def()
+	fmt.Fprintf(canvas.Writer, `<filter id="outline" x="-10%%" y="-10%%" width="120%%" height="120%%" color-interpolation-filters="sRGB">`)
+	fmt.Fprintf(canvas.Writer, `<feMorphology in="SourceAlpha" operator="dilate" radius="%g" result="dilated"/>`, width)
+	fmt.Fprintf(canvas.Writer, `<feFlood flood-color="%s"/>`, outlineColor.ToHTML(true))
+	fmt.Fprintf(canvas.Writer, `<feComposite in2="dilated" operator="in" result="outlined"/>`)
+	fmt.Fprintf(canvas.Writer, `<feMerge><feMergeNode in="outlined"/><feMergeNode in="SourceGraphic"/></feMerge>`)
+	fmt.Fprintf(canvas.Writer, `</filter>`)
+	canvas.DefEnd()
+}
+
 func GetTargetOpacity(color color.RGB) float64 {
 	return MinShadowOpacity + (1-color.PerceivedBrightness()/100)*(MaxShadowOpacity-MinShadowOpacity)
 }
@@ -41,6 +41,8 @@ type Asset struct {
 	DarkColored      bool             // Whether this asset gets adjusted on dark colors
 	DarkBWColored    bool             // Whether this asset has a secondary color adjustmetn on dark backgrounds
 	BLK299           bool             // Opacity replacements for _blk299 assets
+	Weight           int              // Odds of being picked relative to the other options, DefaultWeight if 0
+	Rare             bool             // Only picked by schemes with weighted traits
 }
 
 // getIllustrationPath - get full path of image
@@ -220,7 +222,7 @@ func (sm *assetManager) GetBadgeAssets(btype spc.BadgeType) []Asset {
 		return sm.donorBadgeAssets
 	case spc.BTExchange:
 		return sm.exchBadgeAssets
-	case spc.BTNode:
+	case spc.BTNode, spc.BTNodeTop:
 		return sm.nodeBadgeAssets
 	case spc.BTService:
 		return sm.svcBadgeAssets
@@ -233,9 +235,7 @@ func (sm *assetManager) GetBadgeAssets(btype spc.BadgeType) []Asset {
 func (sm *assetManager) GetHairAssets(sex Sex) []Asset {
 	var ret []Asset
 	for _, v := range sm.hairAssets {
-		if sex == Neutral {
-			ret = append(ret, v)
-		} else if v.Sex == sex || v.Sex == Neutral {
+		if GetRules().MatchesSex(Hair, v, sex) {
 			ret = append(ret, v)
 		}
 	}
@@ -255,14 +255,8 @@ func (sm *assetManager) GetBackHairAssets() []Asset {
 // GetMouthAssets - Get mouth assets
 func (sm *assetManager) GetMouthAssets(sex Sex, luminosity float64) []Asset {
 	var ret []Asset
-	luminosityInt := int(luminosity)
 	for _, v := range sm.mouthAssets {
-		if LightToDarkSwitchPoint > luminosityInt && v.LightOnly {
-			continue
-		}
-		if sex == Neutral {
-			ret = append(ret, v)
-		} else if v.Sex == sex || v.Sex == Neutral {
+		if GetRules().AllowedOn(Mouth, v, luminosity) && GetRules().MatchesSex(Mouth, v, sex) {
 			ret = append(ret, v)
 		}
 	}
@@ -277,16 +271,39 @@ func (sm *assetManager) GetMouthOutlineAssets() []Asset {
 // GetEyeAssets - Get eye asset list
 func (sm *assetManager) GetEyeAssets(sex Sex, luminosity float64) []Asset {
 	var ret []Asset
-	luminosityInt := int(luminosity)
 	for _, v := range sm.eyeAssets {
-		if LightToDarkSwitchPoint > luminosityInt && v.LightOnly {
-			continue
-		}
-		if sex == Neutral {
-			ret = append(ret, v)
-		} else if v.Sex == sex || v.Sex == Neutral {
+		if GetRules().AllowedOn(Eye, v, luminosity) && GetRules().MatchesSex(Eye, v, sex) {
 			ret = append(ret, v)
 		}
 	}
 	return ret
 }
+
+// OfType - every illustration of a type, badges of every kind for Badge
+func (sm *assetManager) OfType(iType IllustrationType) []Asset {
+	switch iType {
+	case Face:
+		return sm.faceAssets
+	case BodyOutline:
+		return sm.bodyOutlineAssets
+	case Badge:
+		var ret []Asset
+		for _, badges := range [][]Asset{sm.donorBadgeAssets, sm.exchBadgeAssets, sm.nodeBadgeAssets, sm.svcBadgeAssets} {
+			ret = append(ret, badges...)
+		}
+		return ret
+	case Hair:
+		return sm.hairAssets
+	case HairBack:
+		return sm.hairBackAssets
+	case HairOutline:
+		return sm.hairOutlineAssets
+	case Mouth:
+		return sm.mouthAssets
+	case MouthOutline:
+		return sm.mouthOutlineAssets
+	case Eye:
+		return sm.eyeAssets
+	}
+	return nil
+}
@@ -9,7 +9,7 @@ import (
 
 // BadgeService is a singleton providing badge/address data
 type badgeService struct {
-	PrincipalReps map[string]bool
+	PrincipalReps map[string]spc.BadgeType
 	Exchanges     map[string]bool
 	Services      map[string]bool
 }
@@ -23,9 +23,9 @@ func GetBadgeSvc() *badgeService {
 		principalReps := db.GetDB().GetPrincipalReps()
 		// Translate everything into a map since
 		// Translate all data into maps since lookup is O(1) instead of O(n)
-		prMap := map[string]bool{}
+		prMap := map[string]spc.BadgeType{}
 		for i := 0; i < len(principalReps); i++ {
-			prMap[principalReps[i]] = true
+			prMap[principalReps[i].PubKey] = principalReps[i].Badge
 		}
 		// Exchanges
 		exchMap := map[string]bool{}
@@ -47,10 +47,10 @@ func GetBadgeSvc() *badgeService {
 }
 
 // UpdatePrincipalReps - Update principal rep map
-func (sm *badgeService) UpdatePrincipalReps(reps []string) {
-	prMap := map[string]bool{}
+func (sm *badgeService) UpdatePrincipalReps(reps []db.Rep) {
+	prMap := map[string]spc.BadgeType{}
 	for i := 0; i < len(reps); i++ {
-		prMap[reps[i]] = true
+		prMap[reps[i].PubKey] = reps[i].Badge
 	}
 	sm.PrincipalReps = prMap
 }
@@ -63,9 +63,9 @@ func (sm *badgeService) GetBadgeType(pk string) spc.BadgeType {
 	} else if sm.Exchanges[pk] {
 		// Exchange
 		return spc.BTExchange
-	} else if sm.PrincipalReps[pk] {
-		// Principal Rep
-		return spc.BTNode
+	} else if tier, ok := sm.PrincipalReps[pk]; ok {
+		// Principal Rep, node or node_top
+		return tier
 	} else if db.GetDB().HasDonorStatus(pk) {
 		// Donor
 		return spc.BTDonor
@@ -0,0 +1,58 @@
+package image
+
+import (
+	"math"
+
+	"github.com/paw-digital/Pawnimals/server/color"
+)
+
+const MinCVDDistance = 0.1    // OKLab distance body and hair keep under every simulated deficiency
+const cvdLightnessStep = 0.01 // How far hair lightness moves per try
+
+// cvdDistance - smallest OKLab distance between a and b under any deficiency
+func cvdDistance(a color.RGB, b color.RGB) float64 {
+	min := math.Inf(1)
+	for _, d := range color.Deficiencies {
+		min = math.Min(min, a.SimulateCVD(d).ToOKLab().Distance(b.SimulateCVD(d).ToOKLab()))
+	}
+	return min
+}
+
+// inGamutAt - lch at lightness l, with chroma reduced until sRGB can show it
+func inGamutAt(lch color.OKLCH, l float64) color.OKLCH {
+	lch.L = l
+	for i := 0; i < 50 && !lch.InGamut(); i++ {
+		lch.C *= 0.9
+	}
+	if !lch.InGamut() {
+		lch.C = 0
+	}
+	return lch
+}
+
+// SeparateForCVD - hair moved lighter or darker, away from the body, until the two stay MinCVDDistance apart under every
+// deficiency. Simulations mostly keep lightness, so lightness is what gets changed. Returns hair as is if it is already apart
+func SeparateForCVD(body color.RGB, hair color.RGB) color.RGB {
+	if cvdDistance(body, hair) >= MinCVDDistance {
+		return hair
+	}
+	bodyL := body.ToOKLab().L
+	lch := hair.ToOKLCH()
+	direction := 1.0
+	if lch.L < bodyL {
+		direction = -1.0
+	}
+	// Try moving away from the body first, then from the body's other side
+	for _, dir := range []float64{direction, -direction} {
+		for l := lch.L + dir*cvdLightnessStep; l >= 0 && l <= 1; l += dir * cvdLightnessStep {
+			if dir != direction && (l-bodyL)*dir < 0 {
+				continue
+			}
+			candidate := inGamutAt(lch, l).ToRGB()
+			if cvdDistance(body, candidate) >= MinCVDDistance {
+				return candidate
+			}
+		}
+	}
+	return hair
+}
@@ -0,0 +1,54 @@
+package image
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/paw-digital/Pawnimals/server/color"
+	"github.com/paw-digital/Pawnimals/server/spc"
+)
+
+func TestSeparateForCVD(t *testing.T) {
+	moved := 0
+	for _, scheme := range Schemes {
+		gen := Generation{Scheme: scheme, CVDSafe: true}
+		for i := 0; i < 2000; i++ {
+			hash := testHash(i)
+			body, hair, err := gen.Colors(hash)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d := cvdDistance(body, hair); d < MinCVDDistance {
+				t.Errorf("Expected body and hair at least %g apart but got %f for %s", MinCVDDistance, d, hash)
+			}
+			plainBody, plainHair, _ := scheme.Colors(hash)
+			if plainBody != body {
+				t.Errorf("Expected body color to be left alone for %s", hash)
+			}
+			if plainHair != hair {
+				moved++
+			}
+		}
+	}
+	if moved == 0 {
+		t.Errorf("Expected some hair colors to be moved")
+	}
+}
+
+func TestCVDPreview(t *testing.T) {
+	accessories, err := GetAccessoriesForHash(testHash(-1), spc.BTNone, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	accessories.CVD = color.Protan
+	svg, err := CombineSVG(accessories)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The svg holds body color rounded to hex, which is what gets simulated
+	body, _ := color.ParseColor(accessories.BodyColor.ToHTML(true))
+	simulated := strings.ToLower(body.SimulateCVD(color.Protan).ToHTML(false))
+	if !strings.Contains(strings.ToLower(string(svg)), simulated) {
+		t.Errorf("Expected simulated body color %s in preview", simulated)
+	}
+}
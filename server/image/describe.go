@@ -0,0 +1,79 @@
+package image
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/paw-digital/Pawnimals/server/spc"
+)
+
+// AltText - accessible name and description of an icon
+type AltText struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+// Words used for each badge in descriptions
+var badgeWords = map[spc.BadgeType]string{
+	spc.BTDonor:    "donor badge",
+	spc.BTExchange: "exchange badge",
+	spc.BTNode:     "node badge",
+	spc.BTNodeTop:  "top node badge",
+	spc.BTService:  "service badge",
+}
+
+// Words used for each legendary variant in descriptions
+var legendaryWords = map[Legendary]string{
+	LegendaryGradient:    "gradient body",
+	LegendaryHolographic: "holographic body",
+	LegendarySparkles:    "sparkles",
+	LegendaryGolden:      "golden outline",
+}
+
+// Matches face file names like 1-face-hedgehog.svg
+var speciesRegex = regexp.MustCompile(`^\d+-face-([a-z-]+)\.svg$`)
+
+// Species - animal a face asset depicts, empty if the file name doesn't say
+func (a Asset) Species() string {
+	m := speciesRegex.FindStringSubmatch(a.FileName)
+	if m == nil {
+		return ""
+	}
+	return strings.ReplaceAll(m[1], "-", " ")
+}
+
+// Describe - traits in plain words, like "teal hedgehog Pawnimal with blue accents, donor badge"
+func Describe(accessories Accessories) string {
+	subject := "Pawnimal"
+	if species := accessories.FaceAsset.Species(); species != "" {
+		subject = species + " " + subject
+	}
+	description := fmt.Sprintf("%s %s with %s accents", accessories.BodyColor.Name(), subject, accessories.HairColor.Name())
+	if words, ok := badgeWords[accessories.BadgeType]; ok && accessories.BadgeAsset != nil {
+		description += ", " + words
+	}
+	if words, ok := legendaryWords[accessories.Legendary]; ok {
+		description += ", " + words
+	}
+	if accessories.Outline && accessories.Legendary != LegendaryGolden {
+		description += fmt.Sprintf(", %s outline", accessories.OutlineColor.Name())
+	}
+	return description
+}
+
+// GetAltText - title naming the address and description of the traits
+func GetAltText(accessories Accessories, address string) AltText {
+	return AltText{
+		Title:       fmt.Sprintf("Pawnimal for %s", shortAddress(address)),
+		Description: Describe(accessories),
+	}
+}
+
+// shortAddress - prefix and last characters, the way wallets abbreviate addresses
+func shortAddress(address string) string {
+	if len(address) <= 20 {
+		return address
+	}
+	return address[:11] + "…" + address[len(address)-6:]
+}
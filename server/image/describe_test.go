@@ -0,0 +1,72 @@
+package image
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/paw-digital/Pawnimals/server/color"
+	"github.com/paw-digital/Pawnimals/server/spc"
+)
+
+func TestSpecies(t *testing.T) {
+	if species := (Asset{FileName: "1-face-hedgehog.svg"}).Species(); species != "hedgehog" {
+		t.Errorf("Expected %s but got %s", "hedgehog", species)
+	}
+	if species := (Asset{FileName: "1_sq-48.svg"}).Species(); species != "" {
+		t.Errorf("Expected no species but got %s", species)
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	body, _ := color.HTMLToRGB("#06c2b5")
+	hair, _ := color.HTMLToRGB("#1e3cff")
+	accessories := Accessories{
+		BodyColor:  body,
+		HairColor:  hair,
+		FaceAsset:  Asset{FileName: "1-face-hedgehog.svg"},
+		BadgeAsset: &Asset{},
+		BadgeType:  spc.BTDonor,
+	}
+	expected := "teal hedgehog Pawnimal with blue accents, donor badge"
+	if got := Describe(accessories); got != expected {
+		t.Errorf("Expected %s but got %s", expected, got)
+	}
+	accessories.BadgeAsset = nil
+	accessories.Outline = true
+	expected = "teal hedgehog Pawnimal with blue accents, black outline"
+	if got := Describe(accessories); got != expected {
+		t.Errorf("Expected %s but got %s", expected, got)
+	}
+}
+
+func TestAccessibleSVG(t *testing.T) {
+	accessories, err := GetAccessoriesForHash("0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef", spc.BTNone, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alt := GetAltText(accessories, "paw_1natrium1o3z5519ifou7xii8crpxpk8y65qmkih8e8bpsjri651oza8imdd")
+	alt.Description += " <&>"
+	accessories.AltText = &alt
+	svg, err := CombineSVG(accessories)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := string(svg)
+	if !strings.Contains(s, `role="img"`) || !strings.Contains(s, `aria-labelledby="title desc"`) {
+		t.Errorf("Expected role and aria-labelledby on the svg element")
+	}
+	// Title has to come first
+	if !strings.Contains(s, `><title id="title">Pawnimal for paw_1natriu…a8imdd</title><desc id="desc">`) {
+		t.Errorf("Expected title and desc as the first children but got %s", s[:300])
+	}
+	if !strings.Contains(s, "&lt;&amp;") {
+		t.Errorf("Expected description to be escaped")
+	}
+	// Namespacing keeps aria references intact
+	ids, refs := idsAndRefs(t, NamespaceIDs(svg, "p1"))
+	for _, ref := range refs {
+		if ids[ref] != 1 {
+			t.Errorf("Expected reference %s to resolve", ref)
+		}
+	}
+}
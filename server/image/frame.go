@@ -0,0 +1,128 @@
+package image
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+
+	svg "github.com/ajstarks/svgo"
+	"github.com/paw-digital/Pawnimals/server/color"
+)
+
+// Shape - mask applied to the whole icon
+type Shape string
+
+const (
+	ShapeNone     Shape = ""
+	ShapeCircle   Shape = "circle"
+	ShapeRounded  Shape = "rounded"
+	ShapeSquircle Shape = "squircle"
+)
+
+// Shapes - every mask that can be requested
+var Shapes = []Shape{ShapeCircle, ShapeRounded, ShapeSquircle}
+
+const MaxPadding = 0.4          // Largest padding, as a fraction of the canvas on each side
+const roundedCornerRadius = 0.2 // Corner radius of the rounded square as a fraction of the canvas
+const squircleExponent = 5.0    // Superellipse exponent, 2 is a circle and higher gets closer to a square
+const squircleSegments = 128    // Points used to approximate the squircle
+const shadowColor = "#000000"   // Drop shadow color
+const shadowOpacity = 0.35      // Drop shadow opacity
+const shadowBlur = 0.015        // Drop shadow blur as a fraction of the canvas
+const shadowOffset = 0.012      // Drop shadow downwards offset as a fraction of the canvas
+
+// Frame - how the icon sits on its canvas, the zero value is a transparent unpadded square
+type Frame struct {
+	Background   *color.RGB // Fill behind the icon, transparent if nil
+	BackgroundTo *color.RGB // When set with Background, a top to bottom gradient between the two
+	Shape        Shape
+	Padding      float64 // Space around the icon as a fraction of the canvas on each side
+	Shadow       bool    // Drop shadow under the icon
+}
+
+// IsZero - whether the frame changes anything
+func (f Frame) IsZero() bool {
+	return f.Background == nil && f.Shape == ShapeNone && f.Padding == 0 && !f.Shadow
+}
+
+// writeFrameDefs - clip path, gradient and shadow filter the frame refers to
+func writeFrameDefs(canvas *svg.SVG, frame Frame) {
+	if frame.IsZero() {
+		return
+	}
+	canvas.Def()
+	switch frame.Shape {
+	case ShapeCircle:
+		fmt.Fprintf(canvas.Writer, `<clipPath id="shape"><circle cx="%d" cy="%d" r="%d"/></clipPath>`, DefaultSize/2, DefaultSize/2, DefaultSize/2)
+	case ShapeRounded:
+		radius := roundedCornerRadius * DefaultSize
+		fmt.Fprintf(canvas.Writer, `<clipPath id="shape"><rect width="%d" height="%d" rx="%g" ry="%g"/></clipPath>`, DefaultSize, DefaultSize, radius, radius)
+	case ShapeSquircle:
+		fmt.Fprintf(canvas.Writer, `<clipPath id="shape"><path d="%s"/></clipPath>`, squirclePath())
+	}
+	if frame.Background != nil && frame.BackgroundTo != nil {
+		fmt.Fprintf(canvas.Writer, `<linearGradient id="background" x1="0" y1="0" x2="0" y2="1"><stop offset="0" stop-color="%s"/><stop offset="1" stop-color="%s"/></linearGradient>`, frame.Background.ToHTML(true), frame.BackgroundTo.ToHTML(true))
+	}
+	if frame.Shadow {
+		fmt.Fprintf(canvas.Writer, `<filter id="shadow" x="-10%%" y="-10%%" width="120%%" height="130%%" color-interpolation-filters="sRGB">`)
+		fmt.Fprintf(canvas.Writer, `<feGaussianBlur in="SourceAlpha" stdDeviation="%g"/>`, shadowBlur*DefaultSize)
+		fmt.Fprintf(canvas.Writer, `<feOffset dy="%g" result="blurred"/>`, shadowOffset*DefaultSize)
+		fmt.Fprintf(canvas.Writer, `<feFlood flood-color="%s" flood-opacity="%g"/>`, shadowColor, shadowOpacity)
+		fmt.Fprintf(canvas.Writer, `<feComposite in2="blurred" operator="in" result="shadowed"/>`)
+		fmt.Fprintf(canvas.Writer, `<feMerge><feMergeNode in="shadowed"/><feMergeNode in="SourceGraphic"/></feMerge>`)
+		fmt.Fprintf(canvas.Writer, `</filter>`)
+	}
+	canvas.DefEnd()
+}
+
+// startFrame - open the groups for the frame and draw the background, returns how many groups to close
+func startFrame(canvas *svg.SVG, frame Frame) int {
+	groups := 0
+	if frame.Shape != ShapeNone {
+		canvas.Group(`clip-path="url(#shape)"`)
+		groups++
+	}
+	if frame.Background != nil {
+		fill := frame.Background.ToHTML(true)
+		if frame.BackgroundTo != nil {
+			fill = "url(#background)"
+		}
+		canvas.Rect(0, 0, DefaultSize, DefaultSize, fmt.Sprintf(`fill="%s"`, fill))
+	}
+	if frame.Padding > 0 {
+		offset := frame.Padding * DefaultSize
+		canvas.Group(fmt.Sprintf(`transform="translate(%g %g) scale(%g)"`, offset, offset, 1-2*frame.Padding))
+		groups++
+	}
+	if frame.Shadow {
+		canvas.Group(`filter="url(#shadow)"`)
+		groups++
+	}
+	return groups
+}
+
+var squircle string
+var squircleOnce sync.Once
+
+// squirclePath - superellipse filling the canvas
+func squirclePath() string {
+	squircleOnce.Do(func() {
+		half := float64(DefaultSize) / 2
+		var b strings.Builder
+		for i := 0; i < squircleSegments; i++ {
+			t := 2 * math.Pi * float64(i) / squircleSegments
+			cos, sin := math.Cos(t), math.Sin(t)
+			x := half + half*math.Copysign(math.Pow(math.Abs(cos), 2/squircleExponent), cos)
+			y := half + half*math.Copysign(math.Pow(math.Abs(sin), 2/squircleExponent), sin)
+			if i == 0 {
+				fmt.Fprintf(&b, "M%.1f %.1f", x, y)
+			} else {
+				fmt.Fprintf(&b, "L%.1f %.1f", x, y)
+			}
+		}
+		b.WriteString("Z")
+		squircle = b.String()
+	})
+	return squircle
+}
@@ -0,0 +1,74 @@
+package image
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	svg "github.com/ajstarks/svgo"
+	"github.com/paw-digital/Pawnimals/server/color"
+)
+
+// frameMarkup - defs and opening groups written for frame, before minifying
+func frameMarkup(frame Frame) (string, int) {
+	var b bytes.Buffer
+	canvas := svg.New(&b)
+	writeFrameDefs(canvas, frame)
+	groups := startFrame(canvas, frame)
+	return b.String(), groups
+}
+
+func TestFrameNone(t *testing.T) {
+	markup, groups := frameMarkup(Frame{})
+	if markup != "" || groups != 0 {
+		t.Errorf("Expected nothing without a frame but got %d groups %s", groups, markup)
+	}
+}
+
+func TestFrameBackground(t *testing.T) {
+	from := color.RGB{R: 255, G: 0, B: 0}
+	to := color.RGB{R: 0, G: 0, B: 255}
+	markup, _ := frameMarkup(Frame{Background: &from})
+	if !strings.Contains(markup, `fill="#ff0000"`) || strings.Contains(markup, `id="background"`) {
+		t.Errorf("Expected a solid #ff0000 background but got %s", markup)
+	}
+	markup, _ = frameMarkup(Frame{Background: &from, BackgroundTo: &to})
+	expected := `<linearGradient id="background" x1="0" y1="0" x2="0" y2="1"><stop offset="0" stop-color="#ff0000"/><stop offset="1" stop-color="#0000ff"/></linearGradient>`
+	if !strings.Contains(markup, expected) {
+		t.Errorf("Expected the gradient %s in %s", expected, markup)
+	}
+	if !strings.Contains(markup, `fill="url(#background)"`) {
+		t.Errorf("Expected the background to be filled with the gradient in %s", markup)
+	}
+}
+
+func TestFrameShapes(t *testing.T) {
+	expected := map[Shape]string{
+		ShapeCircle:   `<clipPath id="shape"><circle cx="540" cy="540" r="540"/></clipPath>`,
+		ShapeRounded:  `<clipPath id="shape"><rect width="1080" height="1080" rx="216" ry="216"/></clipPath>`,
+		ShapeSquircle: `<clipPath id="shape"><path d="M1080.0 540.0L`,
+	}
+	for _, shape := range Shapes {
+		markup, groups := frameMarkup(Frame{Shape: shape})
+		if !strings.Contains(markup, expected[shape]) {
+			t.Errorf("Expected %s to be clipped with %s", shape, expected[shape])
+		}
+		if !strings.Contains(markup, `<g clip-path="url(#shape)"`) || groups != 1 {
+			t.Errorf("Expected %s to open one group applying the clip path", shape)
+		}
+	}
+}
+
+func TestFramePaddingAndShadow(t *testing.T) {
+	markup, groups := frameMarkup(Frame{Padding: 0.25})
+	if !strings.Contains(markup, `transform="translate(270 270) scale(0.5)"`) || groups != 1 {
+		t.Errorf("Expected a quarter padding to shrink the icon to half around the center but got %s", markup)
+	}
+	markup, groups = frameMarkup(Frame{Shape: ShapeCircle, Padding: 0.1, Shadow: true})
+	if !strings.Contains(markup, `<filter id="shadow"`) || !strings.Contains(markup, `filter="url(#shadow)"`) {
+		t.Errorf("Expected a shadow filter in %s", markup)
+	}
+	if groups != 3 {
+		t.Errorf("Expected shape, padding and shadow groups but got %d", groups)
+	}
+}
@@ -0,0 +1,113 @@
+package image
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	svg "github.com/ajstarks/svgo"
+	"github.com/paw-digital/Pawnimals/server/color"
+)
+
+// Legendary - special effect a tiny share of hashes get on top of their traits
+type Legendary string
+
+const (
+	LegendaryNone        Legendary = ""
+	LegendaryGradient    Legendary = "gradient"    // Body fades from the body to the hair color
+	LegendaryHolographic Legendary = "holographic" // Body sweeps through every hue at the body's lightness
+	LegendarySparkles    Legendary = "sparkles"    // Sparkles around the head
+	LegendaryGolden      Legendary = "golden"      // Gold outline
+)
+
+// Legendaries - every variant, a legendary hash gets one of them with equal odds
+var Legendaries = []Legendary{LegendaryGradient, LegendaryHolographic, LegendarySparkles, LegendaryGolden}
+
+const LegendaryOdds = 1000     // One in this many hashes is legendary
+const holographicStops = 7     // Gradient stops of the holographic body, the first and last share a hue
+const holographicMinC = 0.12   // Least OKLCH chroma of the holographic body so the hues show
+const sparkleColor = "#FFF7D6" // Fill of the sparkles
+const legendaryFillID = "legendary"
+
+var goldColor = color.RGB{R: 212, G: 175, B: 55}
+
+// Sparkle centers and sizes in canvas units, around the head
+var sparkles = []struct{ x, y, size float64 }{
+	{140, 130, 60},
+	{945, 170, 45},
+	{90, 820, 40},
+	{985, 760, 55},
+	{540, 60, 35},
+}
+
+// GetLegendary - variant for hash, decided by hash[40:64] which the face-only mode leaves unused
+func GetLegendary(hash string) (Legendary, error) {
+	if len(hash) != 64 {
+		return LegendaryNone, errors.New("Invalid hash")
+	}
+	roll, err := strconv.ParseUint(hash[40:52], 16, 64)
+	if err != nil {
+		return LegendaryNone, err
+	}
+	if roll%LegendaryOdds != 0 {
+		return LegendaryNone, nil
+	}
+	variant, err := strconv.ParseUint(hash[52:64], 16, 64)
+	if err != nil {
+		return LegendaryNone, err
+	}
+	return Legendaries[variant%uint64(len(Legendaries))], nil
+}
+
+// LegendaryFrequency - share of hashes with variant l, exact where sampling would be noisy
+func LegendaryFrequency(l Legendary) float64 {
+	if l == LegendaryNone {
+		return 1 - 1/float64(LegendaryOdds)
+	}
+	return 1 / float64(LegendaryOdds*len(Legendaries))
+}
+
+// legendaryBodyFill - fill of the body, the body color unless the variant paints it
+func legendaryBodyFill(accessories Accessories) string {
+	if accessories.Legendary == LegendaryGradient || accessories.Legendary == LegendaryHolographic {
+		return fmt.Sprintf("url(#%s)", legendaryFillID)
+	}
+	return accessories.BodyColor.ToHTML(true)
+}
+
+// writeLegendaryDefs - gradient the body fill of gradient and holographic variants refers to
+func writeLegendaryDefs(canvas *svg.SVG, accessories Accessories) {
+	switch accessories.Legendary {
+	case LegendaryGradient:
+		canvas.Def()
+		fmt.Fprintf(canvas.Writer, `<linearGradient id="%s" x1="0" y1="0" x2="1" y2="1"><stop offset="0" stop-color="%s"/><stop offset="1" stop-color="%s"/></linearGradient>`, legendaryFillID, accessories.BodyColor.ToHTML(true), accessories.HairColor.ToHTML(true))
+		canvas.DefEnd()
+	case LegendaryHolographic:
+		lch := accessories.BodyColor.ToOKLCH()
+		if lch.C < holographicMinC {
+			lch.C = holographicMinC
+		}
+		canvas.Def()
+		fmt.Fprintf(canvas.Writer, `<linearGradient id="%s" x1="0" y1="0" x2="1" y2="1">`, legendaryFillID)
+		for i := 0; i < holographicStops; i++ {
+			stop := lch
+			stop.H = lch.H + 360*float64(i)/float64(holographicStops-1)
+			fmt.Fprintf(canvas.Writer, `<stop offset="%.3g" stop-color="%s"/>`, float64(i)/float64(holographicStops-1), inGamutAt(stop, lch.L).ToRGB().ToHTML(true))
+		}
+		fmt.Fprintf(canvas.Writer, `</linearGradient>`)
+		canvas.DefEnd()
+	}
+}
+
+// writeSparkles - four pointed stars around the head of the sparkles variant
+func writeSparkles(canvas *svg.SVG, accessories Accessories) {
+	if accessories.Legendary != LegendarySparkles {
+		return
+	}
+	canvas.Gid("sparkles")
+	for _, s := range sparkles {
+		fmt.Fprintf(canvas.Writer, `<path fill="%s" d="M%g %gQ%g %g %g %gQ%g %g %g %gQ%g %g %g %gQ%g %g %g %gZ"/>`, sparkleColor,
+			s.x, s.y-s.size, s.x, s.y, s.x+s.size, s.y, s.x, s.y, s.x, s.y+s.size, s.x, s.y, s.x-s.size, s.y, s.x, s.y, s.x, s.y-s.size)
+	}
+	canvas.Gend()
+}
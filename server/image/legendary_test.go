@@ -0,0 +1,75 @@
+package image
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/paw-digital/Pawnimals/server/spc"
+)
+
+// legendaryHash - a hash with variant l
+func legendaryHash(l Legendary) string {
+	for i, variant := range Legendaries {
+		if variant == l {
+			return testHash(-2)[:40] + fmt.Sprintf("%012x%012x", LegendaryOdds*7, i)
+		}
+	}
+	return ""
+}
+
+func TestGetLegendary(t *testing.T) {
+	found := 0
+	for i := 0; i < 100000; i++ {
+		l, err := GetLegendary(testHash(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if l != LegendaryNone {
+			found++
+		}
+	}
+	if found < 50 || found > 150 {
+		t.Errorf("Expected about 100 legendary hashes in 100000 but got %d", found)
+	}
+	for _, l := range Legendaries {
+		if got, _ := GetLegendary(legendaryHash(l)); got != l {
+			t.Errorf("Expected %s but got %s", l, got)
+		}
+	}
+}
+
+func TestLegendarySVG(t *testing.T) {
+	markers := map[Legendary]string{
+		LegendaryGradient:    "url(#legendary)",
+		LegendaryHolographic: "url(#legendary)",
+		LegendarySparkles:    `id="sparkles"`,
+		LegendaryGolden:      strings.ToLower(goldColor.ToHTML(true)),
+	}
+	for l, marker := range markers {
+		classic, err := GetAccessoriesForHash(legendaryHash(l), spc.BTNone, false, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if classic.Legendary != LegendaryNone {
+			t.Errorf("Expected no legendary variant under the classic scheme but got %s", classic.Legendary)
+		}
+		accessories, err := GetAccessoriesWith(legendaryHash(l), Generation{Scheme: SchemeLegendary}, spc.BTNone, false, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if accessories.Legendary != l {
+			t.Fatalf("Expected %s but got %s", l, accessories.Legendary)
+		}
+		svg, err := CombineSVG(accessories)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(strings.ToLower(string(svg)), marker) {
+			t.Errorf("Expected %s in the %s variant", marker, l)
+		}
+		if desc := Describe(accessories); !strings.Contains(desc, legendaryWords[l]) {
+			t.Errorf("Expected %s in description but got %s", legendaryWords[l], desc)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package image
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+const LegibleSize = 48        // Icons shown at or below this many pixels are simplified
+const MinLegibleStroke = 0.75 // Thinnest stroke, in output pixels, of a simplified icon
+
+const maxShadingOpacity = 0.7 // Translucent fills below this opacity are shading rather than features
+
+// Translucent layers, the 0.15 and 0.65 ones get the opacity GetTargetOpacity computes and the 0.299 ones GetBlk299Opacity
+var shadingLayer = regexp.MustCompile(`<[a-z]+\b[^>]*\sfill-opacity="([0-9.]+)"[^>]*/>`)
+var strokeWidth = regexp.MustCompile(`stroke-width="([0-9.]+)(px)?"`)
+
+// minStrokeWidth - narrowest stroke in canvas units that stays visible at size pixels
+func minStrokeWidth(size int) float64 {
+	return MinLegibleStroke * float64(DefaultSize) / float64(size)
+}
+
+// dropShading - doc without its shading layers
+func dropShading(doc string) string {
+	return shadingLayer.ReplaceAllStringFunc(doc, func(layer string) string {
+		opacity, err := strconv.ParseFloat(shadingLayer.FindStringSubmatch(layer)[1], 64)
+		if err != nil || opacity >= maxShadingOpacity {
+			return layer
+		}
+		return ""
+	})
+}
+
+// simplify - drop shading layers and widen strokes so doc stays readable at size pixels
+func simplify(doc string, size int) string {
+	doc = dropShading(doc)
+	min := minStrokeWidth(size)
+	return strokeWidth.ReplaceAllStringFunc(doc, func(attr string) string {
+		width, err := strconv.ParseFloat(strokeWidth.FindStringSubmatch(attr)[1], 64)
+		if err != nil || width >= min {
+			return attr
+		}
+		return fmt.Sprintf(`stroke-width="%g"`, min)
+	})
+}
@@ -0,0 +1,90 @@
+package image
+
+import (
+	"encoding/xml"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/paw-digital/Pawnimals/server/spc"
+)
+
+func TestSimplify(t *testing.T) {
+	in := `<path fill="#06c2b5" d="M0,0"/><path fill-rule="evenodd" fill-opacity="0.15" d="M1,1"/><path fill-opacity="0.299" d="M1,2"/><path fill-opacity="0.65" d="M1,3"/><path fill-opacity="1" d="M1,4"/><path stroke="#363636" stroke-width="4px" d="M2,2"/><path stroke-width="60" d="M3,3"/>`
+	expected := `<path fill="#06c2b5" d="M0,0"/><path fill-opacity="1" d="M1,4"/><path stroke="#363636" stroke-width="33.75" d="M2,2"/><path stroke-width="60" d="M3,3"/>`
+	if got := simplify(in, 24); got != expected {
+		t.Errorf("Expected %s but got %s", expected, got)
+	}
+}
+
+func TestSmallIconsAreSimplified(t *testing.T) {
+	// Draws the monkey, which has hairline strokes to widen
+	hash := testHash(-9)
+	accessories, err := GetAccessoriesForHash(hash, spc.BTNone, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	full, err := CombineSVG(accessories)
+	if err != nil {
+		t.Fatal(err)
+	}
+	accessories.DisplaySize = 16
+	small, err := CombineSVG(accessories)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(full) == string(small) {
+		t.Errorf("Expected a 16px icon to differ from the full detail one")
+	}
+	if strings.Contains(string(small), `stroke-width="4px"`) {
+		t.Errorf("Expected hairline strokes to be widened at 16px")
+	}
+	accessories.DisplaySize = LegibleSize + 1
+	large, err := CombineSVG(accessories)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(full) != string(large) {
+		t.Errorf("Expected icons above %dpx to keep full detail", LegibleSize)
+	}
+}
+
+var translucentFill = regexp.MustCompile(`fill-opacity="([0-9.]+)"`)
+
+// assertLegible - doc has no shading layers and no stroke too thin to show at size
+func assertLegible(t *testing.T, name string, doc string, size int) {
+	for _, m := range translucentFill.FindAllStringSubmatch(doc, -1) {
+		if opacity, _ := strconv.ParseFloat(m[1], 64); opacity < maxShadingOpacity {
+			t.Errorf("Expected %s to lose its %s shading", name, m[0])
+		}
+	}
+	for _, m := range strokeWidth.FindAllStringSubmatch(doc, -1) {
+		if width, _ := strconv.ParseFloat(m[1], 64); width < minStrokeWidth(size) {
+			t.Errorf("Expected %s to widen its %s stroke", name, m[0])
+		}
+	}
+}
+
+func TestSimplifyAssets(t *testing.T) {
+	var svg SVG
+	for _, iType := range []IllustrationType{Face, Hair, HairBack, Mouth, Eye} {
+		for _, a := range GetAssets().OfType(iType) {
+			if err := xml.Unmarshal(a.SVGContents, &svg); err != nil {
+				t.Fatal(err)
+			}
+			assertLegible(t, a.FileName, simplify(svg.Doc, 16), 16)
+		}
+	}
+	// The hedgehog's whiskers are 4px strokes, a quarter pixel at 16px
+	hedgehog := GetAssets().OfType(Face)[0]
+	if err := xml.Unmarshal(hedgehog.SVGContents, &svg); err != nil {
+		t.Fatal(err)
+	}
+	if hedgehog.FileName != "1-face-hedgehog.svg" || !strings.Contains(svg.Doc, `stroke-width="4px"`) {
+		t.Fatalf("Expected the hedgehog to have 4px strokes")
+	}
+	if simplified := simplify(svg.Doc, 16); strings.Contains(simplified, `stroke-width="4px"`) || !strings.Contains(simplified, `stroke-width="50.625"`) {
+		t.Errorf("Expected the hedgehog's strokes to be widened to 50.625")
+	}
+}
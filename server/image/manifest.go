@@ -0,0 +1,105 @@
+package image
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strconv"
+
+	"github.com/paw-digital/Pawnimals/server/rand"
+)
+
+// DefaultWeight - weight of assets the manifest doesn't weigh
+const DefaultWeight = 100
+
+// ManifestEntry - metadata designers attach to an illustration, the rules engine reads the compatibility fields
+type ManifestEntry struct {
+	Weight      int      `json:"weight"`       // Relative odds of being picked among its options, DefaultWeight if 0
+	Rare        bool     `json:"rare"`         // Only picked by schemes with weighted traits, so adding it keeps older outputs
+	Sex         Sex      `json:"sex"`          // M or F keeps it with assets of the same sex, Neutral if empty
+	LightOnly   bool     `json:"light_only"`   // Only used on bodies at or above LightToDarkSwitchPoint
+	DarkInvert  bool     `json:"dark_invert"`  // Black turns white on dark bodies
+	DarkGray    bool     `json:"dark_gray"`    // White turns gray on dark bodies
+	BLK299      bool     `json:"blk299"`       // 0.299 opacity shading follows the body brightness
+	HairColored bool     `json:"hair_colored"` // Colored with the hair color
+	Faces       []string `json:"faces"`        // Faces a hair or badge fits. Hairs fit every face if empty, badges none
+	Outline     string   `json:"outline"`      // Outline illustration, the one with the same file name if empty
+	BadgeAnchor *Anchor  `json:"badge_anchor"` // Faces only, where the badge's origin goes. Badges stay where drawn if nil
+}
+
+// Anchor - point on the canvas, in canvas units
+type Anchor struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// Manifest - entries by illustration type then file name, read by -load-files into the assets
+type Manifest map[IllustrationType]map[string]ManifestEntry
+
+// LoadManifest - manifest at path, empty if there is no file
+func LoadManifest(path string) (Manifest, error) {
+	raw, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return Manifest{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var m Manifest
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Entry - entry for an illustration, the zero entry if it has none
+func (m Manifest) Entry(iType IllustrationType, fileName string) ManifestEntry {
+	return m[iType][fileName]
+}
+
+// weight - odds of a being picked relative to other options
+func (a Asset) weight() int {
+	if a.Weight <= 0 {
+		return DefaultWeight
+	}
+	return a.Weight
+}
+
+// pickAsset - index of the option entropy picks, -1 if there are none. Unweighted picks are uniform over options that
+// aren't rare, which is how schemes before SchemeWeighted always picked
+func pickAsset(entropy string, options []Asset, weighted bool) (int, error) {
+	randSeed, err := strconv.ParseInt(entropy, 16, 64)
+	if err != nil {
+		return -1, err
+	}
+	r := rand.Init()
+	r.Seed(uint32(randSeed))
+
+	if !weighted {
+		var eligible []int
+		for i, a := range options {
+			if !a.Rare {
+				eligible = append(eligible, i)
+			}
+		}
+		if len(eligible) == 0 {
+			return -1, nil
+		}
+		return eligible[r.Int31n(int32(len(eligible)))], nil
+	}
+
+	total := 0
+	for _, a := range options {
+		total += a.weight()
+	}
+	if total == 0 {
+		return -1, nil
+	}
+	n := int(r.Int31n(int32(total)))
+	for i, a := range options {
+		n -= a.weight()
+		if n < 0 {
+			return i, nil
+		}
+	}
+	return len(options) - 1, nil
+}
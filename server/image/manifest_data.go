@@ -0,0 +1,133 @@
+// Code generated by natricon -load-files. DO NOT EDIT.
+
+package image
+
+// manifestJSON - contents of assets/manifest.json
+var manifestJSON = []byte(`{
+  "face": {
+    "1-face-hedgehog.svg": {"weight": 100},
+    "2-face-elephant.svg": {"weight": 100},
+    "3-face-bear.svg": {"weight": 100},
+    "4-face-cat.svg": {"weight": 100},
+    "5-face-husky.svg": {"weight": 100},
+    "6-face-racoon.svg": {"weight": 100},
+    "7-face-rabbit.svg": {"weight": 100},
+    "8-face-pig.svg": {"weight": 100},
+    "9-face-monkey.svg": {"weight": 100},
+    "10-face-horse.svg": {"weight": 100},
+    "11-face-chicken.svg": {"weight": 100},
+    "12-face-lion.svg": {"weight": 100}
+  },
+  "hair-front": {
+    "1_m.svg": {"sex": "M"},
+    "2_m.svg": {"sex": "M"},
+    "3_m.svg": {"sex": "M"},
+    "4_f.svg": {"sex": "F"},
+    "5_f.svg": {"sex": "F"},
+    "6_m.svg": {"sex": "M"},
+    "7.svg": {},
+    "8_m.svg": {"sex": "M"},
+    "9_m.svg": {"sex": "M"},
+    "10_m.svg": {"sex": "M"},
+    "11_f.svg": {"sex": "F"},
+    "12.svg": {},
+    "13_f.svg": {"sex": "F"},
+    "14_m.svg": {"sex": "M"},
+    "15_m.svg": {"sex": "M"},
+    "16_m.svg": {"sex": "M"},
+    "17_f.svg": {"sex": "F"},
+    "18_f.svg": {"sex": "F"},
+    "19_f.svg": {"sex": "F"},
+    "20_f.svg": {"sex": "F"},
+    "21_f.svg": {"sex": "F"},
+    "22_f.svg": {"sex": "F"},
+    "23_m.svg": {"sex": "M"},
+    "24_m.svg": {"sex": "M"},
+    "25_f.svg": {"sex": "F"},
+    "26.svg": {},
+    "27.svg": {},
+    "28.svg": {},
+    "29_f.svg": {"sex": "F"},
+    "30.svg": {},
+    "31.svg": {},
+    "32.svg": {},
+    "33.svg": {},
+    "34.svg": {}
+  },
+  "hair-back": {
+    "4_f.svg": {"sex": "F"},
+    "5_f.svg": {"sex": "F"},
+    "11_f.svg": {"sex": "F"},
+    "17_f.svg": {"sex": "F"},
+    "18_f.svg": {"sex": "F"},
+    "20_f.svg": {"sex": "F"},
+    "21_f.svg": {"sex": "F"},
+    "24_m.svg": {"sex": "M"},
+    "25_f.svg": {"sex": "F"},
+    "26.svg": {},
+    "29_f.svg": {"sex": "F"}
+  },
+  "mouth": {
+    "1_blk29_sm.svg": {"light_only": true, "blk299": true},
+    "2_blk29_sm.svg": {"light_only": true, "blk299": true},
+    "3_ld_blk29_sm.svg": {"blk299": true},
+    "4_m_hc_ld_mst.svg": {"sex": "M", "hair_colored": true},
+    "5_ld_blk29_sm.svg": {"blk299": true},
+    "6_m_hc_ld_brd.svg": {"sex": "M", "hair_colored": true},
+    "7_ld_blk29_sm.svg": {"blk299": true},
+    "8_ld_blk29_sm.svg": {"blk299": true},
+    "9_f_hc_ld.svg": {"sex": "F", "hair_colored": true},
+    "10_ld_blk29_sm.svg": {"blk299": true},
+    "11_m_hc_ld_mst.svg": {"sex": "M", "hair_colored": true},
+    "12_ld_blk29_sm.svg": {"blk299": true},
+    "13_blk29_sm.svg": {"light_only": true, "blk299": true},
+    "14_blk29_sm.svg": {"light_only": true, "blk299": true},
+    "15_ld_blk29_sm.svg": {"blk299": true},
+    "16_f_hc_ld.svg": {"sex": "F", "hair_colored": true},
+    "17_ld_blk29_sm.svg": {"blk299": true},
+    "18_ld_blk29_sm.svg": {"blk299": true},
+    "19_blk29_sm.svg": {"light_only": true, "blk299": true},
+    "20_blk29_sm.svg": {"light_only": true, "blk299": true}
+  },
+  "eyes": {
+    "1_blk29_e.svg": {"light_only": true, "blk299": true},
+    "2_lod_bw_eg.svg": {"dark_invert": true, "dark_gray": true},
+    "3_lod_bw_eg.svg": {"dark_invert": true, "dark_gray": true},
+    "4_lod_b_blk29_eg.svg": {"dark_invert": true, "blk299": true},
+    "5_lod_b_blk29_eg.svg": {"dark_invert": true, "blk299": true},
+    "6_lod_bw_eg.svg": {"dark_invert": true, "dark_gray": true},
+    "7_blk29_e.svg": {"light_only": true, "blk299": true},
+    "8_lod_b_blk29_eg.svg": {"dark_invert": true, "blk299": true},
+    "9_lod_b_blk29_eg.svg": {"dark_invert": true, "blk299": true},
+    "10_lod_bw_eg.svg": {"dark_invert": true, "dark_gray": true},
+    "11_lod_bw_eg.svg": {"dark_invert": true, "dark_gray": true},
+    "12_lod_bw_eg.svg": {"dark_invert": true, "dark_gray": true},
+    "13_lod_bw_eg.svg": {"dark_invert": true, "dark_gray": true},
+    "14_lod_b_blk29_eg.svg": {"dark_invert": true, "blk299": true},
+    "15_lod_b_blk29_eg.svg": {"dark_invert": true, "blk299": true},
+    "16_lod_b_blk29_eg.svg": {"dark_invert": true, "blk299": true},
+    "17_lod_bw_eg.svg": {"dark_invert": true, "dark_gray": true},
+    "18_lod_bw_eg.svg": {"dark_invert": true, "dark_gray": true},
+    "19_lod_bw_eg.svg": {"dark_invert": true, "dark_gray": true},
+    "20_lod_bw_eg.svg": {"dark_invert": true, "dark_gray": true}
+  },
+  "badge": {
+    "donor_b1_b2_b3_b4_b5_b6_b7_b8_b9_b10.svg": {"faces": ["1-face-hedgehog.svg", "2-face-elephant.svg", "3-face-bear.svg", "4-face-cat.svg", "5-face-husky.svg", "6-face-racoon.svg", "7-face-rabbit.svg", "8-face-pig.svg", "9-face-monkey.svg", "10-face-horse.svg"]},
+    "donor_b11_b12_b13_b14_b15_b16_b17_b18_b19_b20_b21.svg": {"faces": ["11-face-chicken.svg", "12-face-lion.svg"]},
+    "donor_b22_b23_b24_b25_b26_b27_b28_b29.svg": {"faces": []},
+    "donor_b30_b31_b32.svg": {"faces": []},
+    "exchange_b1_b2_b3_b4_b5_b6_b7_b8_b9_b10.svg": {"faces": ["1-face-hedgehog.svg", "2-face-elephant.svg", "3-face-bear.svg", "4-face-cat.svg", "5-face-husky.svg", "6-face-racoon.svg", "7-face-rabbit.svg", "8-face-pig.svg", "9-face-monkey.svg", "10-face-horse.svg"]},
+    "exchange_b11_b12_b13_b14_b15_b16_b17_b18_b19_b20_b21.svg": {"faces": ["11-face-chicken.svg", "12-face-lion.svg"]},
+    "exchange_b22_b23_b24_b25_b26_b27_b28_b29.svg": {"faces": []},
+    "exchange_b30_b31_b32.svg": {"faces": []},
+    "node_b1_b2_b3_b4_b5_b6_b7_b8_b9_b10.svg": {"faces": ["1-face-hedgehog.svg", "2-face-elephant.svg", "3-face-bear.svg", "4-face-cat.svg", "5-face-husky.svg", "6-face-racoon.svg", "7-face-rabbit.svg", "8-face-pig.svg", "9-face-monkey.svg", "10-face-horse.svg"]},
+    "node_b11_b12_b13_b14_b15_b16_b17_b18_b19_b20_b21.svg": {"faces": ["11-face-chicken.svg", "12-face-lion.svg"]},
+    "node_b22_b23_b24_b25_b26_b27_b28_b29.svg": {"faces": []},
+    "node_b30_b31_b32.svg": {"faces": []},
+    "service_b1_b2_b3_b4_b5_b6_b7_b8_b9_b10.svg": {"faces": ["1-face-hedgehog.svg", "2-face-elephant.svg", "3-face-bear.svg", "4-face-cat.svg", "5-face-husky.svg", "6-face-racoon.svg", "7-face-rabbit.svg", "8-face-pig.svg", "9-face-monkey.svg", "10-face-horse.svg"]},
+    "service_b11_b12_b13_b14_b15_b16_b17_b18_b19_b20_b21.svg": {"faces": ["11-face-chicken.svg", "12-face-lion.svg"]},
+    "service_b22_b23_b24_b25_b26_b27_b28_b29.svg": {"faces": []},
+    "service_b30_b31_b32.svg": {"faces": []}
+  }
+}
+`)
@@ -0,0 +1,61 @@
+package image
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/paw-digital/Pawnimals/server/rand"
+)
+
+func TestLoadManifest(t *testing.T) {
+	manifest, err := LoadManifest("../assets/manifest.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	faces := map[string]bool{}
+	for _, face := range GetAssets().GetFaceAssets() {
+		faces[face.FileName] = true
+	}
+	for name, entry := range manifest[Face] {
+		if !faces[name] {
+			t.Errorf("Expected a face asset named %s", name)
+		}
+		if entry.Weight < 0 {
+			t.Errorf("Expected a positive weight for %s but got %d", name, entry.Weight)
+		}
+	}
+	if missing, _ := LoadManifest("missing.json"); len(missing) != 0 {
+		t.Errorf("Expected an empty manifest if there is no file")
+	}
+}
+
+func TestPickAsset(t *testing.T) {
+	options := []Asset{{FileName: "a"}, {FileName: "b", Weight: 300}, {FileName: "rare", Weight: 4, Rare: true}}
+	picks := map[string]int{}
+	for i := 0; i < 20000; i++ {
+		entropy := testHash(i)[:6]
+		idx, err := pickAsset(entropy, options, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Unweighted picks are the uniform picks every older scheme made
+		var seed int64
+		fmt.Sscanf(entropy, "%x", &seed)
+		r := rand.Init()
+		r.Seed(uint32(seed))
+		if want := int(r.Int31n(2)); idx != want {
+			t.Errorf("Expected unweighted pick %d but got %d for %s", want, idx, entropy)
+		}
+		idx, _ = pickAsset(entropy, options, true)
+		picks[options[idx].FileName]++
+	}
+	if picks["rare"] == 0 || picks["rare"] > 400 {
+		t.Errorf("Expected about 200 rare picks but got %d", picks["rare"])
+	}
+	if picks["b"] < 2*picks["a"] {
+		t.Errorf("Expected b about 3 times as often as a but got %d and %d", picks["b"], picks["a"])
+	}
+	if idx, _ := pickAsset("ff", nil, true); idx != -1 {
+		t.Errorf("Expected -1 without options but got %d", idx)
+	}
+}
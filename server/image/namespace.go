@@ -0,0 +1,44 @@
+package image
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Attributes are matched after whitespace so data-id="..." and the like are left alone
+var (
+	idAttrRegex = regexp.MustCompile(`(\s)id="([^"]+)"`)
+	urlRefRegex = regexp.MustCompile(`url\(\s*['"]?#([^)'"\s]+)['"]?\s*\)`)
+	hrefRegex   = regexp.MustCompile(`(\s)((?:xlink:)?href)="#([^"]+)"`)
+	ariaRegex   = regexp.MustCompile(`(\s)(aria-(?:labelledby|describedby))="([^"]+)"`)
+)
+
+// NamespaceIDs - prefix every id and every url(#...), href="#..." or aria id reference in svg
+// so several icons can be inlined in one HTML document without clashing
+func NamespaceIDs(svg []byte, prefix string) []byte {
+	svg = idAttrRegex.ReplaceAll(svg, []byte(`${1}id="`+prefix+`-$2"`))
+	svg = urlRefRegex.ReplaceAll(svg, []byte(`url(#`+prefix+`-$1)`))
+	svg = hrefRegex.ReplaceAll(svg, []byte(`$1$2="#`+prefix+`-$3"`))
+	return ariaRegex.ReplaceAllFunc(svg, func(attr []byte) []byte {
+		m := ariaRegex.FindSubmatch(attr)
+		ids := strings.Fields(string(m[3]))
+		for i := range ids {
+			ids[i] = prefix + "-" + ids[i]
+		}
+		return []byte(fmt.Sprintf(`%s%s="%s"`, m[1], m[2], strings.Join(ids, " ")))
+	})
+}
+
+// InlineIDPrefix - prefix derived from the svg and the caller's instance, so different icons never share it
+// and neither do copies of one icon the caller gave different instances
+func InlineIDPrefix(svg []byte, instance string) string {
+	h := sha256.New()
+	h.Write(svg)
+	h.Write([]byte(instance))
+	sum := h.Sum(nil)
+	// Ids can't start with a digit
+	return "p" + hex.EncodeToString(sum[:6])
+}
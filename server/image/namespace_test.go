@@ -0,0 +1,132 @@
+package image
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/paw-digital/Pawnimals/server/color"
+	"github.com/paw-digital/Pawnimals/server/spc"
+)
+
+func TestNamespaceIDs(t *testing.T) {
+	in := `<svg aria-labelledby="title desc"><g id="face" data-id="face" filter="url(#outline)"><use href="#shape" xlink:href="#shape" data-href="#shape"/><rect fill="url('#background')"/></g>`
+	expected := `<svg aria-labelledby="p1-title p1-desc"><g id="p1-face" data-id="face" filter="url(#p1-outline)"><use href="#p1-shape" xlink:href="#p1-shape" data-href="#shape"/><rect fill="url(#p1-background)"/></g>`
+	if got := string(NamespaceIDs([]byte(in), "p1")); got != expected {
+		t.Errorf("Expected %s but got %s", expected, got)
+	}
+}
+
+// inlineIcon - icon using every kind of reference CombineSVG can emit
+func inlineIcon(t *testing.T, hash string, instance string) []byte {
+	outlineColor := color.RGB{R: 18, G: 52, B: 86}
+	accessories, err := GetAccessoriesForHash(hash, spc.BTNone, true, &outlineColor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := color.RGB{R: 255, G: 255, B: 255}
+	to := color.RGB{R: 0, G: 0, B: 0}
+	accessories.Frame = Frame{Background: &from, BackgroundTo: &to, Shape: ShapeSquircle, Padding: 0.1, Shadow: true}
+	svg, err := CombineSVG(accessories)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NamespaceIDs(svg, InlineIDPrefix(svg, instance))
+}
+
+// idsAndRefs - every id defined and every local reference made in svg
+func idsAndRefs(t *testing.T, svg []byte) (map[string]int, []string) {
+	ids := map[string]int{}
+	decoder := xml.NewDecoder(bytes.NewReader(svg))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("Invalid svg %s", err)
+		}
+		if el, ok := token.(xml.StartElement); ok {
+			for _, attr := range el.Attr {
+				if attr.Name.Local == "id" {
+					ids[attr.Value]++
+				}
+			}
+		}
+	}
+	var refs []string
+	for _, m := range urlRefRegex.FindAllSubmatch(svg, -1) {
+		refs = append(refs, string(m[1]))
+	}
+	for _, m := range hrefRegex.FindAllSubmatch(svg, -1) {
+		refs = append(refs, string(m[3]))
+	}
+	for _, m := range ariaRegex.FindAllSubmatch(svg, -1) {
+		refs = append(refs, strings.Fields(string(m[3]))...)
+	}
+	return ids, refs
+}
+
+func TestInlineIconsDoNotClash(t *testing.T) {
+	first := inlineIcon(t, "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef", "")
+	second := inlineIcon(t, "fedcba9876543210fedcba9876543210fedcba9876543210fedcba9876543210", "")
+	if bytes.Equal(first, second) {
+		t.Fatalf("Expected two different icons")
+	}
+	assertNoClash(t, first, second)
+}
+
+func TestInlineCopiesDoNotClash(t *testing.T) {
+	hash := "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	assertNoClash(t, inlineIcon(t, hash, "header"), inlineIcon(t, hash, "comment-1"))
+}
+
+// assertNoClash - first and second can be inlined on one page, each reference resolving in its own icon
+func assertNoClash(t *testing.T, first []byte, second []byte) {
+	firstIDs, firstRefs := idsAndRefs(t, first)
+	secondIDs, secondRefs := idsAndRefs(t, second)
+	if len(firstRefs) == 0 || len(secondRefs) == 0 {
+		t.Fatalf("Expected icons to reference their own definitions")
+	}
+	// Ids are unique across the page
+	for id, count := range firstIDs {
+		if count > 1 || secondIDs[id] > 0 {
+			t.Errorf("Expected id %s to be unique on the page", id)
+		}
+	}
+	// Every reference resolves within its own icon and never into the other one
+	for _, ref := range firstRefs {
+		if firstIDs[ref] != 1 || secondIDs[ref] != 0 {
+			t.Errorf("Expected reference %s to resolve only in the first icon", ref)
+		}
+	}
+	for _, ref := range secondRefs {
+		if secondIDs[ref] != 1 || firstIDs[ref] != 0 {
+			t.Errorf("Expected reference %s to resolve only in the second icon", ref)
+		}
+	}
+}
+
+func TestIDPrefixOption(t *testing.T) {
+	accessories, err := GetAccessoriesForHash("0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef", spc.BTNone, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	accessories.IDPrefix = "avatar"
+	svg, err := CombineSVG(accessories)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids, refs := idsAndRefs(t, svg)
+	for id := range ids {
+		if len(id) < 7 || id[:7] != "avatar-" {
+			t.Errorf("Expected id %s to be prefixed", id)
+		}
+	}
+	for _, ref := range refs {
+		if ids[ref] != 1 {
+			t.Errorf("Expected reference %s to resolve", ref)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package image
+
+import (
+	"math"
+
+	"github.com/paw-digital/Pawnimals/server/color"
+)
+
+// Lightness and most chroma of surfaces, kept faint so text and the icon stand out on them
+const surfaceLightL = 0.97
+const surfaceLightMaxC = 0.03
+const surfaceDarkL = 0.22
+const surfaceDarkMaxC = 0.04
+
+var black = color.RGB{R: 0, G: 0, B: 0}
+var white = color.RGB{R: 255, G: 255, B: 255}
+
+// Palette - design tokens to theme a page around an icon, every color is exactly what its hex shows
+type Palette struct {
+	Primary        color.RGB // Body color
+	Accent         color.RGB // Hair color
+	OnPrimary      color.RGB // Text on Primary
+	OnAccent       color.RGB // Text on Accent
+	SurfaceLight   color.RGB // Background tinted with Primary for light themes
+	SurfaceDark    color.RGB // Background tinted with Primary for dark themes
+	OnSurfaceLight color.RGB
+	OnSurfaceDark  color.RGB
+}
+
+// PaletteContrast - WCAG 2 contrast ratios of the pairs in a Palette that get used together
+type PaletteContrast struct {
+	OnPrimary          float64 `json:"on_primary"`
+	OnAccent           float64 `json:"on_accent"`
+	OnSurfaceLight     float64 `json:"on_surface_light"`
+	OnSurfaceDark      float64 `json:"on_surface_dark"`
+	PrimaryAccent      float64 `json:"primary_accent"`
+	AccentSurfaceLight float64 `json:"accent_surface_light"`
+	AccentSurfaceDark  float64 `json:"accent_surface_dark"`
+}
+
+// asHex - c as it will be shown once written as hex
+func asHex(c color.RGB) color.RGB {
+	rounded, _ := color.HTMLToRGB(c.ToHTML(false))
+	return rounded
+}
+
+// textOn - black or white, whichever is more readable on bg. One of them always passes ContrastAA
+func textOn(bg color.RGB) color.RGB {
+	if bg.ContrastRatio(black) >= bg.ContrastRatio(white) {
+		return black
+	}
+	return white
+}
+
+// surface - c at lightness l with its chroma capped, keeping its hue
+func surface(c color.RGB, l float64, maxC float64) color.RGB {
+	lch := c.ToOKLCH()
+	lch.C = math.Min(lch.C, maxC)
+	return asHex(inGamutAt(lch, l).ToRGB())
+}
+
+// GetPalette - palette derived from the body and hair colors of accessories
+func GetPalette(accessories Accessories) Palette {
+	var p Palette
+	p.Primary = asHex(accessories.BodyColor)
+	p.Accent = asHex(accessories.HairColor)
+	p.OnPrimary = textOn(p.Primary)
+	p.OnAccent = textOn(p.Accent)
+	p.SurfaceLight = surface(p.Primary, surfaceLightL, surfaceLightMaxC)
+	p.SurfaceDark = surface(p.Primary, surfaceDarkL, surfaceDarkMaxC)
+	p.OnSurfaceLight = textOn(p.SurfaceLight)
+	p.OnSurfaceDark = textOn(p.SurfaceDark)
+	return p
+}
+
+// Contrast - contrast ratios of p, rounded down to two decimals so a shown ratio is never better than the real one
+func (p Palette) Contrast() PaletteContrast {
+	ratio := func(a color.RGB, b color.RGB) float64 {
+		return math.Floor(a.ContrastRatio(b)*100) / 100
+	}
+	return PaletteContrast{
+		OnPrimary:          ratio(p.OnPrimary, p.Primary),
+		OnAccent:           ratio(p.OnAccent, p.Accent),
+		OnSurfaceLight:     ratio(p.OnSurfaceLight, p.SurfaceLight),
+		OnSurfaceDark:      ratio(p.OnSurfaceDark, p.SurfaceDark),
+		PrimaryAccent:      ratio(p.Primary, p.Accent),
+		AccentSurfaceLight: ratio(p.Accent, p.SurfaceLight),
+		AccentSurfaceDark:  ratio(p.Accent, p.SurfaceDark),
+	}
+}
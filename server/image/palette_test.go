@@ -0,0 +1,31 @@
+package image
+
+import (
+	"testing"
+
+	"github.com/paw-digital/Pawnimals/server/color"
+	"github.com/paw-digital/Pawnimals/server/spc"
+)
+
+func TestGetPalette(t *testing.T) {
+	for i := 0; i < 500; i++ {
+		hash := testHash(i)
+		accessories, err := GetAccessoriesForHash(hash, spc.BTNone, false, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p := GetPalette(accessories)
+		if p.Primary.ToHTML(false) != accessories.BodyColor.ToHTML(false) || p.Accent.ToHTML(false) != accessories.HairColor.ToHTML(false) {
+			t.Errorf("Expected primary and accent to be the body and hair colors for %s", hash)
+		}
+		contrast := p.Contrast()
+		for _, ratio := range []float64{contrast.OnPrimary, contrast.OnAccent, contrast.OnSurfaceLight, contrast.OnSurfaceDark} {
+			if ratio < color.ContrastAA {
+				t.Errorf("Expected text contrast of at least %g but got %g for %s", color.ContrastAA, ratio, hash)
+			}
+		}
+		if p.SurfaceLight.RelativeLuminance() < p.SurfaceDark.RelativeLuminance() {
+			t.Errorf("Expected the light surface to be lighter than the dark one for %s", hash)
+		}
+	}
+}
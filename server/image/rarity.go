@@ -0,0 +1,139 @@
+package image
+
+import (
+	"math"
+	"sort"
+
+	"github.com/paw-digital/Pawnimals/server/spc"
+)
+
+// TraitKind - a part of an icon that varies between accounts
+type TraitKind string
+
+const (
+	TraitFace      TraitKind = "face"
+	TraitBodyColor TraitKind = "body_color"
+	TraitHairColor TraitKind = "hair_color"
+	TraitHair      TraitKind = "hair"
+	TraitMouth     TraitKind = "mouth"
+	TraitEye       TraitKind = "eye"
+	TraitLegendary TraitKind = "legendary"
+)
+
+// Trait - one trait of an icon and how often random accounts get it
+type Trait struct {
+	Kind      TraitKind `json:"kind"`
+	Value     string    `json:"value"`
+	Frequency float64   `json:"frequency"` // Share of accounts with this value, 0 if not looked up
+}
+
+// RarityTable - trait frequencies sampled from random accounts, by kind then value
+type RarityTable struct {
+	Samples     int
+	Frequencies map[TraitKind]map[string]float64
+}
+
+// Rarity - traits of an icon with their frequencies and how rare the combination is
+type Rarity struct {
+	Traits []Trait `json:"traits"`
+	Score  float64 `json:"score"`  // Bits of surprise, sum of -log2 of each frequency. Higher is rarer
+	OneIn  float64 `json:"one_in"` // One in this many accounts has every one of these traits, if traits were independent
+}
+
+// Traits - traits of accessories, asset traits only when the icon has that asset
+func Traits(accessories Accessories) []Trait {
+	traits := []Trait{
+		{Kind: TraitFace, Value: accessories.FaceAsset.FileName},
+		{Kind: TraitBodyColor, Value: accessories.BodyColor.Name()},
+		{Kind: TraitHairColor, Value: accessories.HairColor.Name()},
+	}
+	assets := []struct {
+		kind  TraitKind
+		asset *Asset
+	}{
+		{TraitHair, accessories.HairAsset},
+		{TraitMouth, accessories.MouthAsset},
+		{TraitEye, accessories.EyeAsset},
+	}
+	for _, a := range assets {
+		if a.asset != nil {
+			traits = append(traits, Trait{Kind: a.kind, Value: a.asset.FileName})
+		}
+	}
+	if accessories.Legendary != LegendaryNone {
+		traits = append(traits, Trait{Kind: TraitLegendary, Value: string(accessories.Legendary)})
+	}
+	return traits
+}
+
+// BuildRarityTable - frequencies of the traits of samples icons, nextHash gives the hash of each random account
+func BuildRarityTable(gen Generation, samples int, nextHash func() string) (RarityTable, error) {
+	counts := map[TraitKind]map[string]int{}
+	for i := 0; i < samples; i++ {
+		accessories, err := GetAccessoriesWith(nextHash(), gen, spc.BTNone, false, nil)
+		if err != nil {
+			return RarityTable{}, err
+		}
+		for _, t := range Traits(accessories) {
+			// Too rare to sample, Frequency knows their odds
+			if t.Kind == TraitLegendary {
+				continue
+			}
+			if counts[t.Kind] == nil {
+				counts[t.Kind] = map[string]int{}
+			}
+			counts[t.Kind][t.Value]++
+		}
+	}
+	table := RarityTable{Samples: samples, Frequencies: map[TraitKind]map[string]float64{}}
+	for kind, values := range counts {
+		table.Frequencies[kind] = map[string]float64{}
+		for value, n := range values {
+			table.Frequencies[kind][value] = float64(n) / float64(samples)
+		}
+	}
+	return table, nil
+}
+
+// Frequency - share of accounts with value for kind. Values never sampled count as seen once more than the samples
+func (rt RarityTable) Frequency(kind TraitKind, value string) float64 {
+	if kind == TraitLegendary {
+		return LegendaryFrequency(Legendary(value))
+	}
+	if f, ok := rt.Frequencies[kind][value]; ok && f > 0 {
+		return f
+	}
+	return 1 / float64(rt.Samples+1)
+}
+
+// Kinds - trait kinds in the table, sorted
+func (rt RarityTable) Kinds() []TraitKind {
+	var kinds []TraitKind
+	for kind := range rt.Frequencies {
+		kinds = append(kinds, kind)
+	}
+	sort.Slice(kinds, func(i, j int) bool { return kinds[i] < kinds[j] })
+	return kinds
+}
+
+// GetRarityTable - the generated table for gen, classic if there is none. cvd_safe recolors hair so it has tables of its own
+func GetRarityTable(gen Generation) RarityTable {
+	if table, ok := rarityTables[gen]; ok {
+		return table
+	}
+	return rarityTables[Generation{Scheme: SchemeClassic}]
+}
+
+// GetRarity - traits of accessories with their frequencies in table
+func GetRarity(accessories Accessories, table RarityTable) Rarity {
+	rarity := Rarity{Traits: Traits(accessories), OneIn: 1}
+	for i, t := range rarity.Traits {
+		f := table.Frequency(t.Kind, t.Value)
+		rarity.Traits[i].Frequency = f
+		rarity.Score -= math.Log2(f)
+		rarity.OneIn /= f
+	}
+	rarity.Score = math.Round(rarity.Score*100) / 100
+	rarity.OneIn = math.Round(rarity.OneIn)
+	return rarity
+}
@@ -0,0 +1,791 @@
+// Code generated by natricon -rarity-table. DO NOT EDIT.
+
+package image
+
+var rarityTables = map[Generation]RarityTable{
+	{Scheme: 1, CVDSafe: false}: {
+		Samples: 100000,
+		Frequencies: map[TraitKind]map[string]float64{
+			"body_color": {
+				"blue":          0.1252,
+				"brown":         0.02663,
+				"cyan":          0.03248,
+				"dark blue":     0.00602,
+				"dark cyan":     0.00307,
+				"dark gray":     0.00119,
+				"dark green":    0.01928,
+				"dark magenta":  0.00675,
+				"dark pink":     0.00247,
+				"dark purple":   0.00464,
+				"dark red":      0.00499,
+				"dark teal":     0.00664,
+				"gray":          0.00625,
+				"green":         0.194,
+				"light blue":    0.01535,
+				"light cyan":    0.0046,
+				"light gray":    0.00937,
+				"light green":   0.02658,
+				"light magenta": 0.01241,
+				"light orange":  0.00784,
+				"light pink":    0.00513,
+				"light purple":  0.012,
+				"light teal":    0.00944,
+				"light yellow":  0.00702,
+				"magenta":       0.08558,
+				"orange":        0.04611,
+				"pink":          0.04608,
+				"purple":        0.09415,
+				"red":           0.06119,
+				"teal":          0.06468,
+				"white":         0.00543,
+				"yellow":        0.04751,
+			},
+			"face": {
+				"1-face-hedgehog.svg": 0.08455,
+				"10-face-horse.svg":   0.08362,
+				"11-face-chicken.svg": 0.08355,
+				"12-face-lion.svg":    0.0825,
+				"2-face-elephant.svg": 0.08304,
+				"3-face-bear.svg":     0.08283,
+				"4-face-cat.svg":      0.08282,
+				"5-face-husky.svg":    0.08299,
+				"6-face-racoon.svg":   0.08537,
+				"7-face-rabbit.svg":   0.08271,
+				"8-face-pig.svg":      0.08267,
+				"9-face-monkey.svg":   0.08335,
+			},
+			"hair_color": {
+				"blue":          0.1039,
+				"cyan":          0.02828,
+				"gray":          0.02558,
+				"green":         0.1679,
+				"light blue":    0.02898,
+				"light cyan":    0.00832,
+				"light gray":    0.0724,
+				"light green":   0.04876,
+				"light magenta": 0.02181,
+				"light orange":  0.01351,
+				"light pink":    0.00811,
+				"light purple":  0.02158,
+				"light teal":    0.01572,
+				"light yellow":  0.01359,
+				"magenta":       0.07395,
+				"orange":        0.04798,
+				"pink":          0.05056,
+				"purple":        0.07976,
+				"red":           0.05076,
+				"teal":          0.05754,
+				"white":         0.01283,
+				"yellow":        0.04819,
+			},
+		},
+	},
+	{Scheme: 1, CVDSafe: true}: {
+		Samples: 100000,
+		Frequencies: map[TraitKind]map[string]float64{
+			"body_color": {
+				"blue":          0.1252,
+				"brown":         0.02663,
+				"cyan":          0.03248,
+				"dark blue":     0.00602,
+				"dark cyan":     0.00307,
+				"dark gray":     0.00119,
+				"dark green":    0.01928,
+				"dark magenta":  0.00675,
+				"dark pink":     0.00247,
+				"dark purple":   0.00464,
+				"dark red":      0.00499,
+				"dark teal":     0.00664,
+				"gray":          0.00625,
+				"green":         0.194,
+				"light blue":    0.01535,
+				"light cyan":    0.0046,
+				"light gray":    0.00937,
+				"light green":   0.02658,
+				"light magenta": 0.01241,
+				"light orange":  0.00784,
+				"light pink":    0.00513,
+				"light purple":  0.012,
+				"light teal":    0.00944,
+				"light yellow":  0.00702,
+				"magenta":       0.08558,
+				"orange":        0.04611,
+				"pink":          0.04608,
+				"purple":        0.09415,
+				"red":           0.06119,
+				"teal":          0.06468,
+				"white":         0.00543,
+				"yellow":        0.04751,
+			},
+			"face": {
+				"1-face-hedgehog.svg": 0.08455,
+				"10-face-horse.svg":   0.08362,
+				"11-face-chicken.svg": 0.08355,
+				"12-face-lion.svg":    0.0825,
+				"2-face-elephant.svg": 0.08304,
+				"3-face-bear.svg":     0.08283,
+				"4-face-cat.svg":      0.08282,
+				"5-face-husky.svg":    0.08299,
+				"6-face-racoon.svg":   0.08537,
+				"7-face-rabbit.svg":   0.08271,
+				"8-face-pig.svg":      0.08267,
+				"9-face-monkey.svg":   0.08335,
+			},
+			"hair_color": {
+				"blue":          0.1019,
+				"brown":         0.00439,
+				"cyan":          0.02668,
+				"dark blue":     6e-05,
+				"dark gray":     1e-05,
+				"dark magenta":  0.00018,
+				"dark pink":     0.00018,
+				"dark purple":   5e-05,
+				"dark red":      0.00021,
+				"gray":          0.02448,
+				"green":         0.1616,
+				"light blue":    0.0321,
+				"light cyan":    0.00966,
+				"light gray":    0.07145,
+				"light green":   0.05433,
+				"light magenta": 0.02482,
+				"light orange":  0.01527,
+				"light pink":    0.00946,
+				"light purple":  0.02307,
+				"light teal":    0.01588,
+				"light yellow":  0.01408,
+				"magenta":       0.07002,
+				"orange":        0.04361,
+				"pink":          0.05248,
+				"purple":        0.07716,
+				"red":           0.04775,
+				"teal":          0.05537,
+				"white":         0.0188,
+				"yellow":        0.04496,
+			},
+		},
+	},
+	{Scheme: 2, CVDSafe: false}: {
+		Samples: 100000,
+		Frequencies: map[TraitKind]map[string]float64{
+			"body_color": {
+				"blue":          0.08492,
+				"brown":         0.03428,
+				"cyan":          0.06686,
+				"gray":          0.00047,
+				"green":         0.1243,
+				"light blue":    0.07905,
+				"light cyan":    0.01282,
+				"light gray":    0.00643,
+				"light green":   0.01514,
+				"light magenta": 0.03124,
+				"light orange":  0.02847,
+				"light pink":    0.0195,
+				"light purple":  0.04016,
+				"light teal":    0.01115,
+				"light yellow":  0.00642,
+				"magenta":       0.04677,
+				"orange":        0.08327,
+				"pink":          0.08141,
+				"purple":        0.03937,
+				"red":           0.03818,
+				"teal":          0.0968,
+				"white":         0.00042,
+				"yellow":        0.0526,
+			},
+			"face": {
+				"1-face-hedgehog.svg": 0.08455,
+				"10-face-horse.svg":   0.08362,
+				"11-face-chicken.svg": 0.08355,
+				"12-face-lion.svg":    0.0825,
+				"2-face-elephant.svg": 0.08304,
+				"3-face-bear.svg":     0.08283,
+				"4-face-cat.svg":      0.08282,
+				"5-face-husky.svg":    0.08299,
+				"6-face-racoon.svg":   0.08537,
+				"7-face-rabbit.svg":   0.08271,
+				"8-face-pig.svg":      0.08267,
+				"9-face-monkey.svg":   0.08335,
+			},
+			"hair_color": {
+				"blue":          0.08821,
+				"brown":         0.1064,
+				"cyan":          0.04298,
+				"dark blue":     0.01387,
+				"dark cyan":     0.01769,
+				"dark gray":     0.00021,
+				"dark green":    0.04317,
+				"dark magenta":  0.0114,
+				"dark pink":     0.00402,
+				"dark purple":   0.00762,
+				"dark red":      0.00792,
+				"dark teal":     0.03564,
+				"gray":          0.00545,
+				"green":         0.05427,
+				"light blue":    0.04755,
+				"light cyan":    0.01833,
+				"light gray":    0.01307,
+				"light green":   0.03172,
+				"light magenta": 0.02002,
+				"light orange":  0.03179,
+				"light pink":    0.01159,
+				"light purple":  0.0195,
+				"light teal":    0.02096,
+				"light yellow":  0.01515,
+				"magenta":       0.04357,
+				"orange":        0.03127,
+				"pink":          0.05724,
+				"purple":        0.04729,
+				"red":           0.04813,
+				"teal":          0.05383,
+				"white":         0.033,
+				"yellow":        0.01717,
+			},
+		},
+	},
+	{Scheme: 2, CVDSafe: true}: {
+		Samples: 100000,
+		Frequencies: map[TraitKind]map[string]float64{
+			"body_color": {
+				"blue":          0.08492,
+				"brown":         0.03428,
+				"cyan":          0.06686,
+				"gray":          0.00047,
+				"green":         0.1243,
+				"light blue":    0.07905,
+				"light cyan":    0.01282,
+				"light gray":    0.00643,
+				"light green":   0.01514,
+				"light magenta": 0.03124,
+				"light orange":  0.02847,
+				"light pink":    0.0195,
+				"light purple":  0.04016,
+				"light teal":    0.01115,
+				"light yellow":  0.00642,
+				"magenta":       0.04677,
+				"orange":        0.08327,
+				"pink":          0.08141,
+				"purple":        0.03937,
+				"red":           0.03818,
+				"teal":          0.0968,
+				"white":         0.00042,
+				"yellow":        0.0526,
+			},
+			"face": {
+				"1-face-hedgehog.svg": 0.08455,
+				"10-face-horse.svg":   0.08362,
+				"11-face-chicken.svg": 0.08355,
+				"12-face-lion.svg":    0.0825,
+				"2-face-elephant.svg": 0.08304,
+				"3-face-bear.svg":     0.08283,
+				"4-face-cat.svg":      0.08282,
+				"5-face-husky.svg":    0.08299,
+				"6-face-racoon.svg":   0.08537,
+				"7-face-rabbit.svg":   0.08271,
+				"8-face-pig.svg":      0.08267,
+				"9-face-monkey.svg":   0.08335,
+			},
+			"hair_color": {
+				"blue":          0.08821,
+				"brown":         0.1064,
+				"cyan":          0.04298,
+				"dark blue":     0.01387,
+				"dark cyan":     0.01769,
+				"dark gray":     0.00021,
+				"dark green":    0.04318,
+				"dark magenta":  0.0114,
+				"dark pink":     0.00402,
+				"dark purple":   0.00762,
+				"dark red":      0.00792,
+				"dark teal":     0.03564,
+				"gray":          0.00545,
+				"green":         0.05427,
+				"light blue":    0.04755,
+				"light cyan":    0.01833,
+				"light gray":    0.01307,
+				"light green":   0.03172,
+				"light magenta": 0.02002,
+				"light orange":  0.03179,
+				"light pink":    0.01159,
+				"light purple":  0.01951,
+				"light teal":    0.02096,
+				"light yellow":  0.01515,
+				"magenta":       0.04357,
+				"orange":        0.03127,
+				"pink":          0.05723,
+				"purple":        0.04728,
+				"red":           0.04814,
+				"teal":          0.05383,
+				"white":         0.033,
+				"yellow":        0.01717,
+			},
+		},
+	},
+	{Scheme: 3, CVDSafe: false}: {
+		Samples: 100000,
+		Frequencies: map[TraitKind]map[string]float64{
+			"body_color": {
+				"blue":          0.08492,
+				"brown":         0.03428,
+				"cyan":          0.06686,
+				"gray":          0.00047,
+				"green":         0.1243,
+				"light blue":    0.07905,
+				"light cyan":    0.01282,
+				"light gray":    0.00643,
+				"light green":   0.01514,
+				"light magenta": 0.03124,
+				"light orange":  0.02847,
+				"light pink":    0.0195,
+				"light purple":  0.04016,
+				"light teal":    0.01115,
+				"light yellow":  0.00642,
+				"magenta":       0.04677,
+				"orange":        0.08327,
+				"pink":          0.08141,
+				"purple":        0.03937,
+				"red":           0.03818,
+				"teal":          0.0968,
+				"white":         0.00042,
+				"yellow":        0.0526,
+			},
+			"face": {
+				"1-face-hedgehog.svg": 0.08455,
+				"10-face-horse.svg":   0.08362,
+				"11-face-chicken.svg": 0.08355,
+				"12-face-lion.svg":    0.0825,
+				"2-face-elephant.svg": 0.08304,
+				"3-face-bear.svg":     0.08283,
+				"4-face-cat.svg":      0.08282,
+				"5-face-husky.svg":    0.08299,
+				"6-face-racoon.svg":   0.08537,
+				"7-face-rabbit.svg":   0.08271,
+				"8-face-pig.svg":      0.08267,
+				"9-face-monkey.svg":   0.08335,
+			},
+			"hair_color": {
+				"blue":          0.08821,
+				"brown":         0.1064,
+				"cyan":          0.04298,
+				"dark blue":     0.01387,
+				"dark cyan":     0.01769,
+				"dark gray":     0.00021,
+				"dark green":    0.04317,
+				"dark magenta":  0.0114,
+				"dark pink":     0.00402,
+				"dark purple":   0.00762,
+				"dark red":      0.00792,
+				"dark teal":     0.03564,
+				"gray":          0.00545,
+				"green":         0.05427,
+				"light blue":    0.04755,
+				"light cyan":    0.01833,
+				"light gray":    0.01307,
+				"light green":   0.03172,
+				"light magenta": 0.02002,
+				"light orange":  0.03179,
+				"light pink":    0.01159,
+				"light purple":  0.0195,
+				"light teal":    0.02096,
+				"light yellow":  0.01515,
+				"magenta":       0.04357,
+				"orange":        0.03127,
+				"pink":          0.05724,
+				"purple":        0.04729,
+				"red":           0.04813,
+				"teal":          0.05383,
+				"white":         0.033,
+				"yellow":        0.01717,
+			},
+		},
+	},
+	{Scheme: 3, CVDSafe: true}: {
+		Samples: 100000,
+		Frequencies: map[TraitKind]map[string]float64{
+			"body_color": {
+				"blue":          0.08492,
+				"brown":         0.03428,
+				"cyan":          0.06686,
+				"gray":          0.00047,
+				"green":         0.1243,
+				"light blue":    0.07905,
+				"light cyan":    0.01282,
+				"light gray":    0.00643,
+				"light green":   0.01514,
+				"light magenta": 0.03124,
+				"light orange":  0.02847,
+				"light pink":    0.0195,
+				"light purple":  0.04016,
+				"light teal":    0.01115,
+				"light yellow":  0.00642,
+				"magenta":       0.04677,
+				"orange":        0.08327,
+				"pink":          0.08141,
+				"purple":        0.03937,
+				"red":           0.03818,
+				"teal":          0.0968,
+				"white":         0.00042,
+				"yellow":        0.0526,
+			},
+			"face": {
+				"1-face-hedgehog.svg": 0.08455,
+				"10-face-horse.svg":   0.08362,
+				"11-face-chicken.svg": 0.08355,
+				"12-face-lion.svg":    0.0825,
+				"2-face-elephant.svg": 0.08304,
+				"3-face-bear.svg":     0.08283,
+				"4-face-cat.svg":      0.08282,
+				"5-face-husky.svg":    0.08299,
+				"6-face-racoon.svg":   0.08537,
+				"7-face-rabbit.svg":   0.08271,
+				"8-face-pig.svg":      0.08267,
+				"9-face-monkey.svg":   0.08335,
+			},
+			"hair_color": {
+				"blue":          0.08821,
+				"brown":         0.1064,
+				"cyan":          0.04298,
+				"dark blue":     0.01387,
+				"dark cyan":     0.01769,
+				"dark gray":     0.00021,
+				"dark green":    0.04318,
+				"dark magenta":  0.0114,
+				"dark pink":     0.00402,
+				"dark purple":   0.00762,
+				"dark red":      0.00792,
+				"dark teal":     0.03564,
+				"gray":          0.00545,
+				"green":         0.05427,
+				"light blue":    0.04755,
+				"light cyan":    0.01833,
+				"light gray":    0.01307,
+				"light green":   0.03172,
+				"light magenta": 0.02002,
+				"light orange":  0.03179,
+				"light pink":    0.01159,
+				"light purple":  0.01951,
+				"light teal":    0.02096,
+				"light yellow":  0.01515,
+				"magenta":       0.04357,
+				"orange":        0.03127,
+				"pink":          0.05723,
+				"purple":        0.04728,
+				"red":           0.04814,
+				"teal":          0.05383,
+				"white":         0.033,
+				"yellow":        0.01717,
+			},
+		},
+	},
+	{Scheme: 4, CVDSafe: false}: {
+		Samples: 100000,
+		Frequencies: map[TraitKind]map[string]float64{
+			"body_color": {
+				"blue":          0.08492,
+				"brown":         0.03428,
+				"cyan":          0.06686,
+				"gray":          0.00047,
+				"green":         0.1243,
+				"light blue":    0.07905,
+				"light cyan":    0.01282,
+				"light gray":    0.00643,
+				"light green":   0.01514,
+				"light magenta": 0.03124,
+				"light orange":  0.02847,
+				"light pink":    0.0195,
+				"light purple":  0.04016,
+				"light teal":    0.01115,
+				"light yellow":  0.00642,
+				"magenta":       0.04677,
+				"orange":        0.08327,
+				"pink":          0.08141,
+				"purple":        0.03937,
+				"red":           0.03818,
+				"teal":          0.0968,
+				"white":         0.00042,
+				"yellow":        0.0526,
+			},
+			"face": {
+				"1-face-hedgehog.svg": 0.08455,
+				"10-face-horse.svg":   0.08362,
+				"11-face-chicken.svg": 0.08355,
+				"12-face-lion.svg":    0.0825,
+				"2-face-elephant.svg": 0.08304,
+				"3-face-bear.svg":     0.08283,
+				"4-face-cat.svg":      0.08282,
+				"5-face-husky.svg":    0.08299,
+				"6-face-racoon.svg":   0.08537,
+				"7-face-rabbit.svg":   0.08271,
+				"8-face-pig.svg":      0.08267,
+				"9-face-monkey.svg":   0.08335,
+			},
+			"hair_color": {
+				"blue":          0.08821,
+				"brown":         0.1064,
+				"cyan":          0.04298,
+				"dark blue":     0.01387,
+				"dark cyan":     0.01769,
+				"dark gray":     0.00021,
+				"dark green":    0.04317,
+				"dark magenta":  0.0114,
+				"dark pink":     0.00402,
+				"dark purple":   0.00762,
+				"dark red":      0.00792,
+				"dark teal":     0.03564,
+				"gray":          0.00545,
+				"green":         0.05427,
+				"light blue":    0.04755,
+				"light cyan":    0.01833,
+				"light gray":    0.01307,
+				"light green":   0.03172,
+				"light magenta": 0.02002,
+				"light orange":  0.03179,
+				"light pink":    0.01159,
+				"light purple":  0.0195,
+				"light teal":    0.02096,
+				"light yellow":  0.01515,
+				"magenta":       0.04357,
+				"orange":        0.03127,
+				"pink":          0.05724,
+				"purple":        0.04729,
+				"red":           0.04813,
+				"teal":          0.05383,
+				"white":         0.033,
+				"yellow":        0.01717,
+			},
+		},
+	},
+	{Scheme: 4, CVDSafe: true}: {
+		Samples: 100000,
+		Frequencies: map[TraitKind]map[string]float64{
+			"body_color": {
+				"blue":          0.08492,
+				"brown":         0.03428,
+				"cyan":          0.06686,
+				"gray":          0.00047,
+				"green":         0.1243,
+				"light blue":    0.07905,
+				"light cyan":    0.01282,
+				"light gray":    0.00643,
+				"light green":   0.01514,
+				"light magenta": 0.03124,
+				"light orange":  0.02847,
+				"light pink":    0.0195,
+				"light purple":  0.04016,
+				"light teal":    0.01115,
+				"light yellow":  0.00642,
+				"magenta":       0.04677,
+				"orange":        0.08327,
+				"pink":          0.08141,
+				"purple":        0.03937,
+				"red":           0.03818,
+				"teal":          0.0968,
+				"white":         0.00042,
+				"yellow":        0.0526,
+			},
+			"face": {
+				"1-face-hedgehog.svg": 0.08455,
+				"10-face-horse.svg":   0.08362,
+				"11-face-chicken.svg": 0.08355,
+				"12-face-lion.svg":    0.0825,
+				"2-face-elephant.svg": 0.08304,
+				"3-face-bear.svg":     0.08283,
+				"4-face-cat.svg":      0.08282,
+				"5-face-husky.svg":    0.08299,
+				"6-face-racoon.svg":   0.08537,
+				"7-face-rabbit.svg":   0.08271,
+				"8-face-pig.svg":      0.08267,
+				"9-face-monkey.svg":   0.08335,
+			},
+			"hair_color": {
+				"blue":          0.08821,
+				"brown":         0.1064,
+				"cyan":          0.04298,
+				"dark blue":     0.01387,
+				"dark cyan":     0.01769,
+				"dark gray":     0.00021,
+				"dark green":    0.04318,
+				"dark magenta":  0.0114,
+				"dark pink":     0.00402,
+				"dark purple":   0.00762,
+				"dark red":      0.00792,
+				"dark teal":     0.03564,
+				"gray":          0.00545,
+				"green":         0.05427,
+				"light blue":    0.04755,
+				"light cyan":    0.01833,
+				"light gray":    0.01307,
+				"light green":   0.03172,
+				"light magenta": 0.02002,
+				"light orange":  0.03179,
+				"light pink":    0.01159,
+				"light purple":  0.01951,
+				"light teal":    0.02096,
+				"light yellow":  0.01515,
+				"magenta":       0.04357,
+				"orange":        0.03127,
+				"pink":          0.05723,
+				"purple":        0.04728,
+				"red":           0.04814,
+				"teal":          0.05383,
+				"white":         0.033,
+				"yellow":        0.01717,
+			},
+		},
+	},
+	{Scheme: 5, CVDSafe: false}: {
+		Samples: 100000,
+		Frequencies: map[TraitKind]map[string]float64{
+			"body_color": {
+				"blue":          0.08492,
+				"brown":         0.03428,
+				"cyan":          0.06686,
+				"gray":          0.00047,
+				"green":         0.1243,
+				"light blue":    0.07905,
+				"light cyan":    0.01282,
+				"light gray":    0.00643,
+				"light green":   0.01514,
+				"light magenta": 0.03124,
+				"light orange":  0.02847,
+				"light pink":    0.0195,
+				"light purple":  0.04016,
+				"light teal":    0.01115,
+				"light yellow":  0.00642,
+				"magenta":       0.04677,
+				"orange":        0.08327,
+				"pink":          0.08141,
+				"purple":        0.03937,
+				"red":           0.03818,
+				"teal":          0.0968,
+				"white":         0.00042,
+				"yellow":        0.0526,
+			},
+			"face": {
+				"1-face-hedgehog.svg": 0.08455,
+				"10-face-horse.svg":   0.08362,
+				"11-face-chicken.svg": 0.08355,
+				"12-face-lion.svg":    0.0825,
+				"2-face-elephant.svg": 0.08304,
+				"3-face-bear.svg":     0.08283,
+				"4-face-cat.svg":      0.08282,
+				"5-face-husky.svg":    0.08299,
+				"6-face-racoon.svg":   0.08537,
+				"7-face-rabbit.svg":   0.08271,
+				"8-face-pig.svg":      0.08267,
+				"9-face-monkey.svg":   0.08335,
+			},
+			"hair_color": {
+				"blue":          0.08821,
+				"brown":         0.1064,
+				"cyan":          0.04298,
+				"dark blue":     0.01387,
+				"dark cyan":     0.01769,
+				"dark gray":     0.00021,
+				"dark green":    0.04317,
+				"dark magenta":  0.0114,
+				"dark pink":     0.00402,
+				"dark purple":   0.00762,
+				"dark red":      0.00792,
+				"dark teal":     0.03564,
+				"gray":          0.00545,
+				"green":         0.05427,
+				"light blue":    0.04755,
+				"light cyan":    0.01833,
+				"light gray":    0.01307,
+				"light green":   0.03172,
+				"light magenta": 0.02002,
+				"light orange":  0.03179,
+				"light pink":    0.01159,
+				"light purple":  0.0195,
+				"light teal":    0.02096,
+				"light yellow":  0.01515,
+				"magenta":       0.04357,
+				"orange":        0.03127,
+				"pink":          0.05724,
+				"purple":        0.04729,
+				"red":           0.04813,
+				"teal":          0.05383,
+				"white":         0.033,
+				"yellow":        0.01717,
+			},
+		},
+	},
+	{Scheme: 5, CVDSafe: true}: {
+		Samples: 100000,
+		Frequencies: map[TraitKind]map[string]float64{
+			"body_color": {
+				"blue":          0.08492,
+				"brown":         0.03428,
+				"cyan":          0.06686,
+				"gray":          0.00047,
+				"green":         0.1243,
+				"light blue":    0.07905,
+				"light cyan":    0.01282,
+				"light gray":    0.00643,
+				"light green":   0.01514,
+				"light magenta": 0.03124,
+				"light orange":  0.02847,
+				"light pink":    0.0195,
+				"light purple":  0.04016,
+				"light teal":    0.01115,
+				"light yellow":  0.00642,
+				"magenta":       0.04677,
+				"orange":        0.08327,
+				"pink":          0.08141,
+				"purple":        0.03937,
+				"red":           0.03818,
+				"teal":          0.0968,
+				"white":         0.00042,
+				"yellow":        0.0526,
+			},
+			"face": {
+				"1-face-hedgehog.svg": 0.08455,
+				"10-face-horse.svg":   0.08362,
+				"11-face-chicken.svg": 0.08355,
+				"12-face-lion.svg":    0.0825,
+				"2-face-elephant.svg": 0.08304,
+				"3-face-bear.svg":     0.08283,
+				"4-face-cat.svg":      0.08282,
+				"5-face-husky.svg":    0.08299,
+				"6-face-racoon.svg":   0.08537,
+				"7-face-rabbit.svg":   0.08271,
+				"8-face-pig.svg":      0.08267,
+				"9-face-monkey.svg":   0.08335,
+			},
+			"hair_color": {
+				"blue":          0.08821,
+				"brown":         0.1064,
+				"cyan":          0.04298,
+				"dark blue":     0.01387,
+				"dark cyan":     0.01769,
+				"dark gray":     0.00021,
+				"dark green":    0.04318,
+				"dark magenta":  0.0114,
+				"dark pink":     0.00402,
+				"dark purple":   0.00762,
+				"dark red":      0.00792,
+				"dark teal":     0.03564,
+				"gray":          0.00545,
+				"green":         0.05427,
+				"light blue":    0.04755,
+				"light cyan":    0.01833,
+				"light gray":    0.01307,
+				"light green":   0.03172,
+				"light magenta": 0.02002,
+				"light orange":  0.03179,
+				"light pink":    0.01159,
+				"light purple":  0.01951,
+				"light teal":    0.02096,
+				"light yellow":  0.01515,
+				"magenta":       0.04357,
+				"orange":        0.03127,
+				"pink":          0.05723,
+				"purple":        0.04728,
+				"red":           0.04814,
+				"teal":          0.05383,
+				"white":         0.033,
+				"yellow":        0.01717,
+			},
+		},
+	},
+}
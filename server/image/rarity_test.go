@@ -0,0 +1,71 @@
+package image
+
+import (
+	"math"
+	"testing"
+
+	"github.com/paw-digital/Pawnimals/server/spc"
+)
+
+func TestRarityTable(t *testing.T) {
+	for _, scheme := range Schemes {
+		table := GetRarityTable(Generation{Scheme: scheme})
+		if table.Samples == 0 {
+			t.Fatalf("Expected a generated rarity table for scheme %d", scheme)
+		}
+		if cvd := GetRarityTable(Generation{Scheme: scheme, CVDSafe: true}); cvd.Samples == 0 {
+			t.Errorf("Expected a generated rarity table for scheme %d with cvd_safe", scheme)
+		}
+		for _, kind := range table.Kinds() {
+			sum := 0.0
+			for _, f := range table.Frequencies[kind] {
+				sum += f
+			}
+			if math.Abs(sum-1) > 0.01 {
+				t.Errorf("Expected %s frequencies of scheme %d to add up to 1 but got %f", kind, scheme, sum)
+			}
+		}
+		for _, face := range GetAssets().GetFaceAssets() {
+			if _, ok := table.Frequencies[TraitFace][face.FileName]; !ok {
+				t.Errorf("Expected a frequency for %s in scheme %d, regenerate with -rarity-table", face.FileName, scheme)
+			}
+		}
+	}
+}
+
+func TestBuildRarityTable(t *testing.T) {
+	i := 0
+	table, err := BuildRarityTable(Generation{Scheme: SchemeClassic}, 1000, func() string {
+		i++
+		return testHash(i)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table.Samples != 1000 || len(table.Frequencies[TraitFace]) == 0 {
+		t.Errorf("Expected face frequencies from 1000 samples but got %v", table)
+	}
+	if f := table.Frequency(TraitFace, "missing.svg"); f != 1.0/1001 {
+		t.Errorf("Expected unseen values to count as seen once but got %f", f)
+	}
+}
+
+func TestGetRarity(t *testing.T) {
+	hash := testHash(-3)
+	accessories, err := GetAccessoriesForHash(hash, spc.BTNone, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	table := RarityTable{Samples: 10, Frequencies: map[TraitKind]map[string]float64{
+		TraitFace:      {accessories.FaceAsset.FileName: 0.5},
+		TraitBodyColor: {accessories.BodyColor.Name(): 0.25},
+		TraitHairColor: {accessories.HairColor.Name(): 0.5},
+	}}
+	rarity := GetRarity(accessories, table)
+	if len(rarity.Traits) != 3 {
+		t.Fatalf("Expected 3 traits but got %d", len(rarity.Traits))
+	}
+	if rarity.Score != 4 || rarity.OneIn != 16 {
+		t.Errorf("Expected a score of 4 and one in 16 but got %f and %f", rarity.Score, rarity.OneIn)
+	}
+}
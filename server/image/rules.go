@@ -0,0 +1,192 @@
+package image
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Outline illustration of each type that has one
+var outlineTypes = map[IllustrationType]IllustrationType{
+	Face:  BodyOutline,
+	Hair:  HairOutline,
+	Mouth: MouthOutline,
+}
+
+// Rules - which illustrations go together, declared in assets/manifest.json instead of file names
+type Rules struct {
+	manifest Manifest
+}
+
+var rulesSingleton *Rules
+var onceRules sync.Once
+
+// GetRules - rules of the manifest -load-files embedded
+func GetRules() *Rules {
+	onceRules.Do(func() {
+		var m Manifest
+		if err := json.Unmarshal(manifestJSON, &m); err != nil {
+			panic("Failed to decode asset manifest")
+		}
+		rulesSingleton = NewRules(m)
+	})
+	return rulesSingleton
+}
+
+// NewRules - rules of manifest m
+func NewRules(m Manifest) *Rules {
+	return &Rules{manifest: m}
+}
+
+// Entry - manifest entry of an illustration
+func (r *Rules) Entry(iType IllustrationType, a Asset) ManifestEntry {
+	return r.manifest.Entry(iType, a.FileName)
+}
+
+// Sex - sex of an illustration, Neutral unless the manifest gives one
+func (r *Rules) Sex(iType IllustrationType, a Asset) Sex {
+	if sex := r.Entry(iType, a).Sex; sex != "" {
+		return sex
+	}
+	return Neutral
+}
+
+// MatchesSex - whether a can go with an icon of sex, Neutral on either side goes with anything
+func (r *Rules) MatchesSex(iType IllustrationType, a Asset, sex Sex) bool {
+	own := r.Sex(iType, a)
+	return sex == Neutral || own == Neutral || own == sex
+}
+
+// AllowedOn - whether a can be drawn on a body with this perceived brightness
+func (r *Rules) AllowedOn(iType IllustrationType, a Asset, luminosity float64) bool {
+	return !r.Entry(iType, a).LightOnly || int(luminosity) >= LightToDarkSwitchPoint
+}
+
+// Fits - whether a hair or badge fits face
+func (r *Rules) Fits(iType IllustrationType, a Asset, face Asset) bool {
+	faces := r.Entry(iType, a).Faces
+	if len(faces) == 0 {
+		return iType != Badge
+	}
+	for _, f := range faces {
+		if f == face.FileName {
+			return true
+		}
+	}
+	return false
+}
+
+// Outline - outline illustration of a among outlines, nil if it has none
+func (r *Rules) Outline(iType IllustrationType, a Asset, outlines []Asset) *Asset {
+	name := r.Entry(iType, a).Outline
+	if name == "" {
+		name = a.FileName
+	}
+	for _, o := range outlines {
+		if o.FileName == name {
+			return &o
+		}
+	}
+	return nil
+}
+
+// Badge - first of badges that fits face, nil if none do
+func (r *Rules) Badge(face Asset, badges []Asset) *Asset {
+	for _, b := range badges {
+		if r.Fits(Badge, b, face) {
+			return &b
+		}
+	}
+	return nil
+}
+
+// BadgeAnchor - where the badge of face goes, nil to leave it where it is drawn
+func (r *Rules) BadgeAnchor(face Asset) *Anchor {
+	return r.Entry(Face, face).BadgeAnchor
+}
+
+// Validate - error naming the first combination of accessories the rules don't allow
+func (r *Rules) Validate(accessories Accessories) error {
+	face := accessories.FaceAsset
+	if hair := accessories.HairAsset; hair != nil && !r.Fits(Hair, *hair, face) {
+		return fmt.Errorf("hair %s does not fit face %s", hair.FileName, face.FileName)
+	}
+	if badge := accessories.BadgeAsset; badge != nil && !r.Fits(Badge, *badge, face) {
+		return fmt.Errorf("badge %s does not fit face %s", badge.FileName, face.FileName)
+	}
+	parts := []struct {
+		iType IllustrationType
+		asset *Asset
+	}{
+		{Face, &face},
+		{Hair, accessories.HairAsset},
+		{Mouth, accessories.MouthAsset},
+		{Eye, accessories.EyeAsset},
+	}
+	sex, sexOf := Neutral, ""
+	luminosity := accessories.BodyColor.PerceivedBrightness()
+	for _, p := range parts {
+		if p.asset == nil {
+			continue
+		}
+		if !r.AllowedOn(p.iType, *p.asset, luminosity) {
+			return fmt.Errorf("%s %s is for light bodies only", p.iType, p.asset.FileName)
+		}
+		if !r.MatchesSex(p.iType, *p.asset, sex) {
+			return fmt.Errorf("%s %s does not match the sex of %s", p.iType, p.asset.FileName, sexOf)
+		}
+		if own := r.Sex(p.iType, *p.asset); own != Neutral {
+			sex, sexOf = own, p.asset.FileName
+		}
+	}
+	return nil
+}
+
+// ValidateManifest - every problem with the manifest given the illustrations in assets, sorted
+func (r *Rules) ValidateManifest(assets *assetManager) []error {
+	var errs []error
+	known := func(iType IllustrationType, name string) bool {
+		for _, a := range assets.OfType(iType) {
+			if a.FileName == name {
+				return true
+			}
+		}
+		return false
+	}
+	for iType, entries := range r.manifest {
+		for name, e := range entries {
+			if !known(iType, name) {
+				errs = append(errs, fmt.Errorf("%s %s: no such illustration", iType, name))
+			}
+			if e.Weight < 0 {
+				errs = append(errs, fmt.Errorf("%s %s: weight can't be negative", iType, name))
+			}
+			if e.Sex != "" && e.Sex != Male && e.Sex != Female && e.Sex != Neutral {
+				errs = append(errs, fmt.Errorf("%s %s: sex must be M, F or N", iType, name))
+			}
+			for _, face := range e.Faces {
+				if !known(Face, face) {
+					errs = append(errs, fmt.Errorf("%s %s: no face %s", iType, name, face))
+				} else if faceSex := r.manifest.Entry(Face, face).Sex; e.Sex != "" && faceSex != "" && e.Sex != Neutral && faceSex != Neutral && faceSex != e.Sex {
+					errs = append(errs, fmt.Errorf("%s %s: fits face %s of the other sex", iType, name, face))
+				}
+			}
+			if e.Outline != "" {
+				if outlineType, ok := outlineTypes[iType]; !ok || !known(outlineType, e.Outline) {
+					errs = append(errs, fmt.Errorf("%s %s: no outline %s", iType, name, e.Outline))
+				}
+			}
+			if a := e.BadgeAnchor; a != nil && (iType != Face || a.X < 0 || a.Y < 0 || a.X > DefaultSize || a.Y > DefaultSize) {
+				errs = append(errs, fmt.Errorf("%s %s: badge anchors go on faces, inside the canvas", iType, name))
+			}
+		}
+	}
+	for _, b := range assets.OfType(Badge) {
+		if len(r.manifest.Entry(Badge, b.FileName).Faces) == 0 {
+			errs = append(errs, fmt.Errorf("%s %s: fits no face", Badge, b.FileName))
+		}
+	}
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+	return errs
+}
@@ -0,0 +1,111 @@
+package image
+
+import (
+	"bytes"
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/paw-digital/Pawnimals/server/color"
+	"github.com/paw-digital/Pawnimals/server/spc"
+)
+
+func TestManifestIsEmbedded(t *testing.T) {
+	raw, err := ioutil.ReadFile("../assets/manifest.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(raw, manifestJSON) {
+		t.Errorf("Expected manifest_data.go to match assets/manifest.json, run -load-files")
+	}
+}
+
+var unfittedBadge = regexp.MustCompile(`_b(22|30)_`)
+
+func TestValidateManifest(t *testing.T) {
+	// Badges drawn for bodies 22 to 32 have no face to go on, everything else is valid
+	for _, err := range GetRules().ValidateManifest(GetAssets()) {
+		if !strings.HasPrefix(err.Error(), "badge ") || !strings.HasSuffix(err.Error(), ": fits no face") || !unfittedBadge.MatchString(err.Error()) {
+			t.Errorf("Expected a valid manifest but got %s", err)
+		}
+	}
+	bad := NewRules(Manifest{
+		Face: {"missing.svg": {}},
+		Hair: {"1_m.svg": {Sex: "X", Faces: []string{"nope.svg"}, Outline: "nope.svg"}},
+	})
+	// Every badge illustration fits no face without a badge entry
+	want := 4 + len(GetAssets().OfType(Badge))
+	if errs := bad.ValidateManifest(GetAssets()); len(errs) != want {
+		t.Errorf("Expected %d problems but got %v", want, errs)
+	}
+}
+
+func TestEveryFaceHasBadges(t *testing.T) {
+	for _, face := range GetAssets().GetFaceAssets() {
+		for _, btype := range []spc.BadgeType{spc.BTDonor, spc.BTExchange, spc.BTNode, spc.BTService} {
+			if GetBadgeAsset(face, btype) == nil {
+				t.Errorf("Expected a %s badge for %s", btype, face.FileName)
+			}
+		}
+	}
+}
+
+func TestRulesMatchAssets(t *testing.T) {
+	rules := GetRules()
+	for _, iType := range []IllustrationType{Face, Hair, HairBack, Mouth, Eye} {
+		for _, a := range GetAssets().OfType(iType) {
+			e := rules.Entry(iType, a)
+			if rules.Sex(iType, a) != a.Sex {
+				t.Errorf("Expected sex %s for %s %s but got %s", a.Sex, iType, a.FileName, rules.Sex(iType, a))
+			}
+			if iType == Mouth || iType == Eye {
+				if e.LightOnly != a.LightOnly || e.DarkInvert != a.DarkColored || e.DarkGray != a.DarkBWColored || e.BLK299 != a.BLK299 {
+					t.Errorf("Expected the manifest entry of %s %s to match its flags", iType, a.FileName)
+				}
+			}
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	face := Asset{FileName: "face.svg"}
+	other := Asset{FileName: "other.svg"}
+	rules := NewRules(Manifest{
+		Face:  {"face.svg": {Sex: Female}, "other.svg": {BadgeAnchor: &Anchor{X: 10, Y: 20}}},
+		Hair:  {"hair.svg": {Faces: []string{"other.svg"}}, "male.svg": {Sex: Male}},
+		Mouth: {"mouth.svg": {LightOnly: true}},
+		Badge: {"donor_b1.svg": {Faces: []string{"other.svg"}}, "donor_b10.svg": {Faces: []string{"face.svg"}}},
+	})
+	light := color.RGB{R: 255, G: 255, B: 255}
+	dark := color.RGB{R: 10, G: 10, B: 10}
+	cases := []struct {
+		accessories Accessories
+		valid       bool
+	}{
+		{Accessories{FaceAsset: face, BodyColor: light}, true},
+		{Accessories{FaceAsset: face, BodyColor: light, HairAsset: &Asset{FileName: "hair.svg"}}, false},
+		{Accessories{FaceAsset: other, BodyColor: light, HairAsset: &Asset{FileName: "hair.svg"}}, true},
+		{Accessories{FaceAsset: face, BodyColor: light, HairAsset: &Asset{FileName: "male.svg"}}, false},
+		{Accessories{FaceAsset: other, BodyColor: light, HairAsset: &Asset{FileName: "male.svg"}}, true},
+		{Accessories{FaceAsset: face, BodyColor: light, MouthAsset: &Asset{FileName: "mouth.svg"}}, true},
+		{Accessories{FaceAsset: face, BodyColor: dark, MouthAsset: &Asset{FileName: "mouth.svg"}}, false},
+		{Accessories{FaceAsset: face, BodyColor: light, BadgeAsset: &Asset{FileName: "donor_b1.svg"}}, false},
+	}
+	for i, c := range cases {
+		if err := rules.Validate(c.accessories); (err == nil) != c.valid {
+			t.Errorf("Expected case %d valid to be %v but got %v", i, c.valid, err)
+		}
+	}
+	// Badges match exact face names, b1 no longer matches b10
+	badges := []Asset{{FileName: "donor_b10.svg"}, {FileName: "donor_b1.svg"}}
+	if b := rules.Badge(other, badges); b == nil || b.FileName != "donor_b1.svg" {
+		t.Errorf("Expected donor_b1.svg but got %v", b)
+	}
+	if a := rules.BadgeAnchor(other); a == nil || a.X != 10 || a.Y != 20 {
+		t.Errorf("Expected the badge anchor of other.svg but got %v", a)
+	}
+	if o := rules.Outline(Hair, Asset{FileName: "hair.svg"}, []Asset{{FileName: "hair.svg"}}); o == nil {
+		t.Errorf("Expected the outline with the same file name")
+	}
+}
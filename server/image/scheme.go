@@ -0,0 +1,149 @@
+package image
+
+import (
+	"errors"
+	"math"
+	"strconv"
+
+	"github.com/paw-digital/Pawnimals/server/color"
+	"github.com/paw-digital/Pawnimals/server/rand"
+)
+
+// Scheme - version of the algorithm turning a hash into colors and traits, a new scheme changes every natricon so old ones stay selectable
+type Scheme int
+
+const (
+	SchemeClassic   Scheme = 1 // RGB within a perceived brightness band, hair a saturation and brightness variant of the body
+	SchemeOKLCH     Scheme = 2 // Even hue coverage in OKLCH with guaranteed body and hair contrast
+	SchemeWeighted  Scheme = 3 // OKLCH colors, traits picked by manifest weight including rare ones
+	SchemeLegendary Scheme = 4 // Scheme 3 plus legendary variants for a rare share of hashes
+	SchemeBadged    Scheme = 5 // Scheme 4 plus badges on vanities, fitted to each face by the manifest
+)
+
+const DefaultScheme = SchemeClassic
+
+// Schemes - every scheme that can be selected
+var Schemes = []Scheme{SchemeClassic, SchemeOKLCH, SchemeWeighted, SchemeLegendary, SchemeBadged}
+
+// OKLCH scheme limits, lightness is 0..1
+const oklchBodyMinL = 0.55
+const oklchBodyMaxL = 0.85
+const oklchMinChroma = 0.04
+const oklchMaxChroma = 0.22
+const oklchHairHueShift = 60.0     // Hair hue is within this many degrees of the body's
+const MinHairLightnessDelta = 0.15 // OKLab lightness between body and hair, which bounds their distance from below
+const oklchHairLightnessRange = 0.15
+const oklchHairMinL = 0.35
+const oklchHairMaxL = 0.95
+
+// Generation - how colors are picked for a hash
+type Generation struct {
+	Scheme  Scheme
+	CVDSafe bool // Keep body and hair apart under every simulated color vision deficiency
+}
+
+// Colors - body and hair colors for hash
+func (g Generation) Colors(hash string) (color.RGB, color.RGB, error) {
+	body, hair, err := g.Scheme.Colors(hash)
+	if err != nil || !g.CVDSafe {
+		return body, hair, err
+	}
+	return body, SeparateForCVD(body, hair), nil
+}
+
+// ParseScheme - scheme from its version number
+func ParseScheme(version string) (Scheme, error) {
+	v, err := strconv.Atoi(version)
+	if err == nil {
+		for _, s := range Schemes {
+			if Scheme(v) == s {
+				return s, nil
+			}
+		}
+	}
+	return DefaultScheme, errors.New("Valid schemes are 1 to 5")
+}
+
+// Colors - body and hair colors for hash, scheme 0 is DefaultScheme
+func (s Scheme) Colors(hash string) (color.RGB, color.RGB, error) {
+	if s >= SchemeOKLCH {
+		return GetOKLCHColors(hash)
+	}
+	body, err := GetBodyColor(hash[0:16])
+	if err != nil {
+		return color.RGB{}, color.RGB{}, err
+	}
+	hair, err := GetHairColor(body, hash[16:26], hash[26:30], hash[30:34])
+	return body, hair, err
+}
+
+// WeightedTraits - whether traits are picked by manifest weight. Older schemes pick uniformly and never pick rare assets
+func (s Scheme) WeightedTraits() bool {
+	return s >= SchemeWeighted
+}
+
+// LegendaryVariants - whether a rare share of hashes gets a legendary variant. Older schemes never do, so their icons stay as they were
+func (s Scheme) LegendaryVariants() bool {
+	return s >= SchemeLegendary
+}
+
+// Badges - whether vanities get their badge drawn. Older schemes never drew one, so their vanities stay as they were
+func (s Scheme) Badges() bool {
+	return s >= SchemeBadged
+}
+
+// entropyUnit - deterministic number in 0..1 from hex entropy
+func entropyUnit(entropy string) (float64, error) {
+	randSeed, err := strconv.ParseInt(entropy, 16, 64)
+	if err != nil {
+		return 0, err
+	}
+	r := rand.Init()
+	r.Seed(uint32(randSeed))
+	return float64(r.Int31n(1000*1000)) / (1000 * 1000), nil
+}
+
+// maxInGamutChroma - highest chroma up to oklchMaxChroma that sRGB can show at lightness l and hue h
+func maxInGamutChroma(l float64, h float64) float64 {
+	lo, hi := 0.0, oklchMaxChroma
+	if (color.OKLCH{L: l, C: hi, H: h}).InGamut() {
+		return hi
+	}
+	for i := 0; i < 24; i++ {
+		mid := (lo + hi) / 2
+		if (color.OKLCH{L: l, C: mid, H: h}).InGamut() {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return lo
+}
+
+// GetOKLCHColors - body and hair colors of the OKLCH scheme, using the same parts of hash as the classic scheme
+func GetOKLCHColors(hash string) (color.RGB, color.RGB, error) {
+	var u [7]float64
+	for i, part := range []string{hash[0:4], hash[4:8], hash[8:12], hash[16:20], hash[20:24], hash[26:30], hash[30:34]} {
+		var err error
+		if u[i], err = entropyUnit(part); err != nil {
+			return color.RGB{}, color.RGB{}, err
+		}
+	}
+	// Body hue is uniform, which OKLCH makes look uniform too
+	body := color.OKLCH{H: u[0] * 360, L: oklchBodyMinL + u[1]*(oklchBodyMaxL-oklchBodyMinL)}
+	maxC := maxInGamutChroma(body.L, body.H)
+	body.C = math.Min(oklchMinChroma, maxC) + u[2]*math.Max(maxC-oklchMinChroma, 0)
+
+	// Hair is a neighbouring hue, lighter or darker by at least MinHairLightnessDelta
+	hair := color.OKLCH{H: math.Mod(body.H+(u[3]*2-1)*oklchHairHueShift+360, 360)}
+	delta := MinHairLightnessDelta + u[6]*oklchHairLightnessRange
+	if u[5] < 0.5 {
+		delta = -delta
+	}
+	hair.L = body.L + delta
+	if hair.L < oklchHairMinL || hair.L > oklchHairMaxL {
+		hair.L = body.L - delta
+	}
+	hair.C = math.Min(body.C*(0.5+u[4]), maxInGamutChroma(hair.L, hair.H))
+	return body.ToRGB(), hair.ToRGB(), nil
+}
@@ -0,0 +1,52 @@
+package image
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"testing"
+)
+
+// testHash - i-th of a fixed sequence of hashes, so tests cover many accounts and still run the same every time
+func testHash(i int) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(fmt.Sprint(i))))
+}
+
+func TestOKLCHScheme(t *testing.T) {
+	for i := 0; i < 2000; i++ {
+		hash := testHash(i)
+		body, hair, err := SchemeOKLCH.Colors(hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d := body.ToOKLab().Distance(hair.ToOKLab()); d < MinHairLightnessDelta-1e-3 {
+			t.Errorf("Expected body and hair at least %g apart but got %f for %s", MinHairLightnessDelta, d, hash)
+		}
+		for _, c := range []float64{body.R, body.G, body.B, hair.R, hair.G, hair.B} {
+			if c < 0 || c > 255 {
+				t.Errorf("Expected colors inside sRGB but got %v and %v for %s", body, hair, hash)
+			}
+		}
+		again, _, _ := SchemeOKLCH.Colors(hash)
+		if again != body {
+			t.Errorf("Expected the same colors for the same hash")
+		}
+	}
+}
+
+func TestParseScheme(t *testing.T) {
+	if s, err := ParseScheme("2"); err != nil || s != SchemeOKLCH {
+		t.Errorf("Expected %d but got %d, %v", SchemeOKLCH, s, err)
+	}
+	if s, err := ParseScheme("3"); err != nil || s != SchemeWeighted {
+		t.Errorf("Expected %d but got %d, %v", SchemeWeighted, s, err)
+	}
+	if s, err := ParseScheme("4"); err != nil || s != SchemeLegendary {
+		t.Errorf("Expected %d but got %d, %v", SchemeLegendary, s, err)
+	}
+	if s, err := ParseScheme("5"); err != nil || s != SchemeBadged {
+		t.Errorf("Expected %d but got %d, %v", SchemeBadged, s, err)
+	}
+	if _, err := ParseScheme("6"); err == nil {
+		t.Errorf("Expected error for unknown scheme")
+	}
+}
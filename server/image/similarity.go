@@ -0,0 +1,54 @@
+package image
+
+import "math"
+
+// Verdict - how easily two icons can be told apart
+type Verdict string
+
+const (
+	VerdictLookAlike Verdict = "look-alike" // Easily mistaken for each other
+	VerdictSimilar   Verdict = "similar"    // Same animal in close colors, a careful look tells them apart
+	VerdictDistinct  Verdict = "distinct"
+)
+
+const similarColorRange = 0.25 // OKLab distance at which two colors stop looking alike at all
+const faceWeight = 0.4         // Share of the score from having the same face
+const bodyWeight = 0.4         // Share of the score from body color closeness
+const hairWeight = 0.2         // Share of the score from hair color closeness
+const LookAlikeScore = 0.85    // Score at or above which two icons are look-alikes
+const SimilarScore = 0.65      // Score at or above which two icons are similar, different faces never get here
+
+// Similarity - how alike two icons look, Score is 1 for identical traits and 0 for nothing in common
+type Similarity struct {
+	Score        float64 `json:"score"`
+	Verdict      Verdict `json:"verdict"`
+	SameFace     bool    `json:"same_face"`
+	BodyDistance float64 `json:"body_distance"` // OKLab distance of the body colors
+	HairDistance float64 `json:"hair_distance"` // OKLab distance of the hair colors
+}
+
+// colorCloseness - 1 for the same color down to 0 at similarColorRange apart
+func colorCloseness(distance float64) float64 {
+	return math.Max(0, 1-distance/similarColorRange)
+}
+
+// Compare - similarity of the traits of two icons
+func Compare(a Accessories, b Accessories) Similarity {
+	var sim Similarity
+	sim.SameFace = a.FaceAsset.FileName == b.FaceAsset.FileName
+	sim.BodyDistance = a.BodyColor.ToOKLab().Distance(b.BodyColor.ToOKLab())
+	sim.HairDistance = a.HairColor.ToOKLab().Distance(b.HairColor.ToOKLab())
+	if sim.SameFace {
+		sim.Score += faceWeight
+	}
+	sim.Score += bodyWeight*colorCloseness(sim.BodyDistance) + hairWeight*colorCloseness(sim.HairDistance)
+	switch {
+	case sim.Score >= LookAlikeScore:
+		sim.Verdict = VerdictLookAlike
+	case sim.Score >= SimilarScore:
+		sim.Verdict = VerdictSimilar
+	default:
+		sim.Verdict = VerdictDistinct
+	}
+	return sim
+}
@@ -0,0 +1,38 @@
+package image
+
+import (
+	"testing"
+
+	"github.com/paw-digital/Pawnimals/server/color"
+	"github.com/paw-digital/Pawnimals/server/spc"
+)
+
+func TestCompare(t *testing.T) {
+	hash := testHash(-4)
+	a, err := GetAccessoriesForHash(hash, spc.BTNone, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sim := Compare(a, a); sim.Score != 1 || sim.Verdict != VerdictLookAlike {
+		t.Errorf("Expected identical icons to score 1 but got %v", sim)
+	}
+	b := a
+	b.BodyColor = color.RGB{R: a.BodyColor.R + 4, G: a.BodyColor.G, B: a.BodyColor.B}
+	if sim := Compare(a, b); sim.Verdict != VerdictLookAlike {
+		t.Errorf("Expected a barely different body color to look alike but got %v", sim)
+	}
+	for _, face := range GetAssets().GetFaceAssets() {
+		if face.FileName != a.FaceAsset.FileName {
+			b.FaceAsset = face
+		}
+	}
+	if sim := Compare(a, b); sim.Verdict != VerdictDistinct {
+		t.Errorf("Expected a different face to be distinct but got %v", sim)
+	}
+	c := a
+	c.BodyColor = color.RGB{R: 255 - a.BodyColor.R, G: 255 - a.BodyColor.G, B: 255 - a.BodyColor.B}
+	c.HairColor = color.RGB{R: 255 - a.HairColor.R, G: 255 - a.HairColor.G, B: 255 - a.HairColor.B}
+	if sim := Compare(a, c); sim.Verdict != VerdictDistinct {
+		t.Errorf("Expected inverted colors to be distinct but got %v", sim)
+	}
+}
@@ -0,0 +1,147 @@
+package image
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+
+	svg "github.com/ajstarks/svgo"
+	"github.com/paw-digital/Pawnimals/server/color"
+)
+
+// Style - how the icon is drawn
+type Style string
+
+const (
+	StyleFlat  Style = ""      // Vector art as drawn
+	StylePixel Style = "pixel" // Low-res grid in a palette derived from the body and hair colors
+	StyleMono  Style = "mono"  // Pure black and white for e-ink and thermal printers
+)
+
+// Styles - every style that can be requested besides flat
+var Styles = []Style{StylePixel, StyleMono}
+
+// ParseStyle - style by name, flat if empty
+func ParseStyle(name string) (Style, error) {
+	name = strings.ToLower(name)
+	if name == "" || name == "flat" {
+		return StyleFlat, nil
+	}
+	for _, s := range Styles {
+		if string(s) == name {
+			return s, nil
+		}
+	}
+	return StyleFlat, errors.New("Valid styles are 'flat', 'pixel' or 'mono'")
+}
+
+const PixelGrid = 30       // Cells across a pixel style icon
+const pixelShade = 0.35    // Brightness of the body color used for the pixel style's dark features
+const MonoThreshold = 50.0 // Perceived brightness at or above which mono draws white
+
+// Color attributes to remap, url() and none are left alone
+var colorAttr = regexp.MustCompile(`(fill|stroke|stop-color|flood-color)="([^"]+)"`)
+
+// writeStyleDefs - filters the style refers to
+func writeStyleDefs(canvas *svg.SVG, style Style) {
+	if style != StylePixel {
+		return
+	}
+	// Sample one point per cell and grow it to fill the cell, sampling keeps colors exact where averaging would blend them
+	cell := float64(DefaultSize) / PixelGrid
+	canvas.Def()
+	fmt.Fprintf(canvas.Writer, `<filter id="pixelate" filterUnits="userSpaceOnUse" x="0" y="0" width="%d" height="%d">`, DefaultSize, DefaultSize)
+	fmt.Fprintf(canvas.Writer, `<feFlood x="%g" y="%g" width="1" height="1"/>`, math.Floor(cell/2), math.Floor(cell/2))
+	fmt.Fprintf(canvas.Writer, `<feComposite width="%g" height="%g"/>`, cell, cell)
+	fmt.Fprintf(canvas.Writer, `<feTile result="grid"/>`)
+	fmt.Fprintf(canvas.Writer, `<feComposite in="SourceGraphic" in2="grid" operator="in"/>`)
+	fmt.Fprintf(canvas.Writer, `<feMorphology operator="dilate" radius="%g"/>`, math.Floor(cell/2))
+	fmt.Fprintf(canvas.Writer, `</filter>`)
+	canvas.DefEnd()
+}
+
+// startStyle - open the style's group, returns how many groups to close
+func startStyle(canvas *svg.SVG, style Style) int {
+	if style != StylePixel {
+		return 0
+	}
+	canvas.Group(`filter="url(#pixelate)"`)
+	return 1
+}
+
+// stylePalette - colors the style may use, nil for any
+func stylePalette(accessories Accessories) []color.RGB {
+	switch accessories.Style {
+	case StylePixel:
+		body := accessories.BodyColor
+		palette := []color.RGB{
+			body,
+			accessories.HairColor,
+			{R: body.R * pixelShade, G: body.G * pixelShade, B: body.B * pixelShade},
+			{R: 255.0, G: 255.0, B: 255.0},
+		}
+		if accessories.Outline {
+			palette = append(palette, accessories.OutlineColor)
+		}
+		if accessories.Frame.Background != nil {
+			palette = append(palette, *accessories.Frame.Background)
+		}
+		if accessories.Frame.BackgroundTo != nil {
+			palette = append(palette, *accessories.Frame.BackgroundTo)
+		}
+		return palette
+	case StyleMono:
+		return []color.RGB{{R: 0, G: 0, B: 0}, {R: 255.0, G: 255.0, B: 255.0}}
+	}
+	return nil
+}
+
+// monoColor - black or white by perceived brightness
+func monoColor(c color.RGB) color.RGB {
+	if c.PerceivedBrightness() >= MonoThreshold {
+		return color.RGB{R: 255.0, G: 255.0, B: 255.0}
+	}
+	return color.RGB{R: 0, G: 0, B: 0}
+}
+
+// nearestColor - closest of palette to c
+func nearestColor(c color.RGB, palette []color.RGB) color.RGB {
+	nearest := palette[0]
+	best := math.Inf(1)
+	for _, p := range palette {
+		d := (c.R-p.R)*(c.R-p.R) + (c.G-p.G)*(c.G-p.G) + (c.B-p.B)*(c.B-p.B)
+		if d < best {
+			best = d
+			nearest = p
+		}
+	}
+	return nearest
+}
+
+// applyStylePalette - remap every color in doc into the style's palette
+func applyStylePalette(doc []byte, accessories Accessories) []byte {
+	palette := stylePalette(accessories)
+	if palette == nil {
+		return doc
+	}
+	return recolor(doc, func(c color.RGB) color.RGB {
+		if accessories.Style == StyleMono {
+			return monoColor(c)
+		}
+		return nearestColor(c, palette)
+	})
+}
+
+// recolor - replace every color attribute in doc with what fn makes of it
+func recolor(doc []byte, fn func(color.RGB) color.RGB) []byte {
+	return colorAttr.ReplaceAllFunc(doc, func(attr []byte) []byte {
+		m := colorAttr.FindSubmatch(attr)
+		c, err := color.ParseColor(string(m[2]))
+		if err != nil {
+			return attr
+		}
+		return []byte(fmt.Sprintf(`%s="%s"`, m[1], fn(c).ToHTML(true)))
+	})
+}
@@ -0,0 +1,51 @@
+package image
+
+import (
+	"testing"
+
+	"github.com/paw-digital/Pawnimals/server/color"
+	"github.com/paw-digital/Pawnimals/server/spc"
+)
+
+// styleColors - every color CombineSVG drew for accessories in style
+func styleColors(t *testing.T, accessories Accessories, style Style) map[string]bool {
+	accessories.Style = style
+	svg, err := CombineSVG(accessories)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, _ := CombineSVG(accessories)
+	if string(svg) != string(again) {
+		t.Errorf("Expected %s style to be deterministic", style)
+	}
+	colors := map[string]bool{}
+	for _, m := range colorAttr.FindAllSubmatch(svg, -1) {
+		if c, err := color.ParseColor(string(m[2])); err == nil {
+			colors[c.ToHTML(true)] = true
+		}
+	}
+	return colors
+}
+
+func TestStylePalettes(t *testing.T) {
+	hash := testHash(-4)
+	accessories, err := GetAccessoriesForHash(hash, spc.BTNone, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for c := range styleColors(t, accessories, StyleMono) {
+		if c != "#000000" && c != "#ffffff" {
+			t.Errorf("Expected only black and white in mono but got %s", c)
+		}
+	}
+	palette := map[string]bool{}
+	accessories.Style = StylePixel
+	for _, c := range stylePalette(accessories) {
+		palette[c.ToHTML(true)] = true
+	}
+	for c := range styleColors(t, accessories, StylePixel) {
+		if !palette[c] {
+			t.Errorf("Expected %s to be in the pixel palette", c)
+		}
+	}
+}
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -10,56 +12,22 @@ import (
 	"strings"
 
 	"github.com/paw-digital/Pawnimals/server/image"
+	"github.com/paw-digital/Pawnimals/server/spc"
 	"github.com/golang/glog"
 )
 
-// getSex - get Sex based on image name
-func getSex(name string) image.Sex {
-	if strings.Contains(name, "_f") {
-		return image.Female
-	} else if strings.Contains(name, "_m") {
-		return image.Male
-	}
-	return image.Neutral
-}
-
-// getLightOnly - get whether this asset should only be available on light backgrounds
-func getLightOnly(name string) bool {
-	if strings.Contains(name, "_lod") || strings.Contains(name, "_ld") {
-		return false
-	}
-	return true
-}
-
-// getDarkColored - get whether this asset should have colors altered
-func getDarkColored(name string) bool {
-	if strings.Contains(name, "_lod_b") {
-		return true
-	}
-	return false
-}
-
-// getDarkBWColored - get whether this asset should have colors altered
-func getDarkBWColored(name string) bool {
-	if strings.Contains(name, "_lod_bw") {
-		return true
-	}
-	return false
-}
-
-func getBlk299(name string) bool {
-	if strings.Contains(name, "_blk29") {
-		return true
-	}
-	return false
-}
-
 func LoadAssetsToArray() {
 	wd, err := os.Getwd()
 	if err != nil {
 		panic("Can't get working directory")
 	}
 
+	manifest, err := image.LoadManifest(path.Join(wd, "assets", "manifest.json"))
+	if err != nil {
+		glog.Fatalf("Couldn't load asset manifest: %s", err)
+	}
+	rules := image.NewRules(manifest)
+
 	ret := "package image\n\n"
 
 	var faceAsset image.Asset
@@ -80,8 +48,11 @@ func LoadAssetsToArray() {
 			faceAsset.LightOnly = false
 			faceAsset.DarkColored = false
 			faceAsset.DarkBWColored = false
-			faceAsset.Sex = getSex(info.Name())
+			faceAsset.Sex = rules.Sex(image.Face, faceAsset)
 			faceAsset.BLK299 = false
+			entry := manifest.Entry(image.Face, info.Name())
+			faceAsset.Weight = entry.Weight
+			faceAsset.Rare = entry.Rare
 			encoded, _ := json.Marshal(faceAsset)
 			ret += strings.ReplaceAll(strings.ReplaceAll(strings.ReplaceAll(fmt.Sprint(encoded), "[", "{"), "]", "}"), " ", ", ") + ","
 		}
@@ -105,7 +76,7 @@ func LoadAssetsToArray() {
 			bodyOutlineAsset.HairColored = false
 			bodyOutlineAsset.BodyColored = false
 			bodyOutlineAsset.DarkBWColored = false
-			bodyOutlineAsset.Sex = getSex(info.Name())
+			bodyOutlineAsset.Sex = rules.Sex(image.BodyOutline, bodyOutlineAsset)
 			bodyOutlineAsset.BLK299 = false
 			encoded, _ := json.Marshal(bodyOutlineAsset)
 			ret += strings.ReplaceAll(strings.ReplaceAll(strings.ReplaceAll(fmt.Sprint(encoded), "[", "{"), "]", "}"), " ", ", ") + ","
@@ -237,8 +208,11 @@ func LoadAssetsToArray() {
 			hairAssets.LightOnly = false
 			hairAssets.DarkColored = false
 			hairAssets.DarkBWColored = false
-			hairAssets.Sex = getSex(info.Name())
+			hairAssets.Sex = rules.Sex(image.Hair, hairAssets)
 			hairAssets.BLK299 = false
+			entry := manifest.Entry(image.Hair, info.Name())
+			hairAssets.Weight = entry.Weight
+			hairAssets.Rare = entry.Rare
 			encoded, _ := json.Marshal(hairAssets)
 			ret += strings.ReplaceAll(strings.ReplaceAll(strings.ReplaceAll(fmt.Sprint(encoded), "[", "{"), "]", "}"), " ", ", ") + ","
 		}
@@ -261,7 +235,7 @@ func LoadAssetsToArray() {
 			}
 			hairBackAssets.HairColored = true
 			hairBackAssets.BodyColored = false
-			hairBackAssets.Sex = getSex(info.Name())
+			hairBackAssets.Sex = rules.Sex(image.HairBack, hairBackAssets)
 			hairBackAssets.LightOnly = false
 			hairBackAssets.DarkColored = false
 			hairBackAssets.DarkBWColored = false
@@ -288,7 +262,7 @@ func LoadAssetsToArray() {
 			}
 			hairOutlineAsset.HairColored = false
 			hairOutlineAsset.BodyColored = false
-			hairOutlineAsset.Sex = getSex(info.Name())
+			hairOutlineAsset.Sex = rules.Sex(image.HairOutline, hairOutlineAsset)
 			hairOutlineAsset.LightOnly = false
 			hairOutlineAsset.DarkColored = false
 			hairOutlineAsset.DarkBWColored = false
@@ -313,13 +287,16 @@ func LoadAssetsToArray() {
 				glog.Fatalf("Couldn't load file %s", eyeAssets.IllustrationPath)
 				panic(err.Error())
 			}
+			entry := manifest.Entry(image.Eye, info.Name())
 			eyeAssets.HairColored = false
 			eyeAssets.BodyColored = false
-			eyeAssets.Sex = getSex(info.Name())
-			eyeAssets.LightOnly = getLightOnly(info.Name())
-			eyeAssets.DarkColored = getDarkColored(info.Name())
-			eyeAssets.DarkBWColored = getDarkBWColored(info.Name())
-			eyeAssets.BLK299 = getBlk299(info.Name())
+			eyeAssets.Sex = rules.Sex(image.Eye, eyeAssets)
+			eyeAssets.LightOnly = entry.LightOnly
+			eyeAssets.DarkColored = entry.DarkInvert
+			eyeAssets.DarkBWColored = entry.DarkGray
+			eyeAssets.BLK299 = entry.BLK299
+			eyeAssets.Weight = entry.Weight
+			eyeAssets.Rare = entry.Rare
 			encoded, _ := json.Marshal(eyeAssets)
 			ret += strings.ReplaceAll(strings.ReplaceAll(strings.ReplaceAll(fmt.Sprint(encoded), "[", "{"), "]", "}"), " ", ", ") + ","
 		}
@@ -340,13 +317,16 @@ func LoadAssetsToArray() {
 				glog.Fatalf("Couldn't load file %s", mouthAssets.IllustrationPath)
 				panic(err.Error())
 			}
-			mouthAssets.HairColored = strings.Contains(info.Name(), "_hc")
+			entry := manifest.Entry(image.Mouth, info.Name())
+			mouthAssets.HairColored = entry.HairColored
 			mouthAssets.BodyColored = false
-			mouthAssets.Sex = getSex(info.Name())
-			mouthAssets.LightOnly = getLightOnly(info.Name())
-			mouthAssets.DarkColored = getDarkColored(info.Name())
-			mouthAssets.DarkBWColored = getDarkBWColored(info.Name())
-			mouthAssets.BLK299 = getBlk299(info.Name())
+			mouthAssets.Sex = rules.Sex(image.Mouth, mouthAssets)
+			mouthAssets.LightOnly = entry.LightOnly
+			mouthAssets.DarkColored = entry.DarkInvert
+			mouthAssets.DarkBWColored = entry.DarkGray
+			mouthAssets.BLK299 = entry.BLK299
+			mouthAssets.Weight = entry.Weight
+			mouthAssets.Rare = entry.Rare
 			encoded, _ := json.Marshal(mouthAssets)
 			ret += strings.ReplaceAll(strings.ReplaceAll(strings.ReplaceAll(fmt.Sprint(encoded), "[", "{"), "]", "}"), " ", ", ") + ","
 		}
@@ -369,7 +349,7 @@ func LoadAssetsToArray() {
 			}
 			mouthOutlineAsset.HairColored = false
 			mouthOutlineAsset.BodyColored = false
-			mouthOutlineAsset.Sex = getSex(info.Name())
+			mouthOutlineAsset.Sex = rules.Sex(image.MouthOutline, mouthOutlineAsset)
 			mouthOutlineAsset.LightOnly = false
 			mouthOutlineAsset.DarkColored = false
 			mouthOutlineAsset.DarkBWColored = false
@@ -388,4 +368,51 @@ func LoadAssetsToArray() {
 		fmt.Printf("Failed to open file for writing %s", output)
 	}
 	outputF.WriteString(ret)
+
+	if err := writeManifestData(wd); err != nil {
+		glog.Fatalf("Couldn't write asset manifest: %s", err)
+	}
+}
+
+// writeManifestData - embed assets/manifest.json for the rules engine
+func writeManifestData(wd string) error {
+	raw, err := ioutil.ReadFile(path.Join(wd, "assets", "manifest.json"))
+	if err != nil {
+		return err
+	}
+	if bytes.Contains(raw, []byte("`")) {
+		return errors.New("manifest can't contain backquotes")
+	}
+	ret := "// Code generated by natricon -load-files. DO NOT EDIT.\n\npackage image\n\n"
+	ret += "// manifestJSON - contents of assets/manifest.json\n"
+	ret += "var manifestJSON = []byte(`" + string(raw) + "`)\n"
+	return ioutil.WriteFile(path.Join(wd, "image", "manifest_data.go"), []byte(ret), os.FileMode(0644))
+}
+
+// ValidateAssets - print every problem with assets/manifest.json and the vanities, returns how many there are
+func ValidateAssets() int {
+	wd, err := os.Getwd()
+	if err != nil {
+		panic("Can't get working directory")
+	}
+	manifest, err := image.LoadManifest(path.Join(wd, "assets", "manifest.json"))
+	if err != nil {
+		fmt.Printf("assets/manifest.json: %s\n", err)
+		return 1
+	}
+	rules := image.NewRules(manifest)
+	problems := rules.ValidateManifest(image.GetAssets())
+	for pubKey, v := range spc.Vanities {
+		if v.FaceAssetID <= 0 || v.BodyColor == nil || v.HairColor == nil {
+			continue
+		}
+		accessories := image.GetSpecificNatricon(v.Badge, false, nil, v.BodyColor, v.HairColor, v.FaceAssetID, v.HairAssetID, v.MouthAssetID, v.EyeAssetID)
+		if err := rules.Validate(accessories); err != nil {
+			problems = append(problems, fmt.Errorf("vanity %s: %s", pubKey, err))
+		}
+	}
+	for _, p := range problems {
+		fmt.Println(p)
+	}
+	return len(problems)
 }
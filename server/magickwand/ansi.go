@@ -0,0 +1,62 @@
+package magickwand
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+const DefaultANSIWidth = 32 // Columns of terminal output
+const MinANSIWidth = 8
+const MaxANSIWidth = 160
+
+// Pixels less opaque than this are left to the terminal's background
+const ansiAlphaCutoff = 128
+
+// ConvertSvgToANSI - the svg as width columns of half blocks with 24-bit color escapes, two pixels per character
+func ConvertSvgToANSI(svgData []byte, width uint) ([]byte, error) {
+	raster, err := ConvertSvgToBinary(svgData, "png", width)
+	if err != nil {
+		return nil, err
+	}
+	img, err := png.Decode(bytes.NewReader(raster))
+	if err != nil {
+		return nil, err
+	}
+	return EncodeANSI(img), nil
+}
+
+// EncodeANSI - img drawn with upper and lower half blocks, top pixel in the foreground and bottom in the background
+func EncodeANSI(img image.Image) []byte {
+	var b bytes.Buffer
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += 2 {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			top, topOk := ansiPixel(img, x, y)
+			bottom, bottomOk := ansiPixel(img, x, y+1)
+			switch {
+			case topOk && bottomOk:
+				fmt.Fprintf(&b, "\x1b[38;2;%d;%d;%dm\x1b[48;2;%d;%d;%dm▀", top.R, top.G, top.B, bottom.R, bottom.G, bottom.B)
+			case topOk:
+				fmt.Fprintf(&b, "\x1b[0m\x1b[38;2;%d;%d;%dm▀", top.R, top.G, top.B)
+			case bottomOk:
+				fmt.Fprintf(&b, "\x1b[0m\x1b[38;2;%d;%d;%dm▄", bottom.R, bottom.G, bottom.B)
+			default:
+				b.WriteString("\x1b[0m ")
+			}
+		}
+		b.WriteString("\x1b[0m\n")
+	}
+	return b.Bytes()
+}
+
+// ansiPixel - color at x, y and whether it is opaque enough to draw
+func ansiPixel(img image.Image, x int, y int) (color.NRGBA, bool) {
+	if !(image.Point{x, y}.In(img.Bounds())) {
+		return color.NRGBA{}, false
+	}
+	c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+	return c, c.A >= ansiAlphaCutoff
+}
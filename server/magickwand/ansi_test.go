@@ -0,0 +1,27 @@
+package magickwand
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestEncodeANSI(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 3, 3))
+	img.Set(0, 0, color.NRGBA{R: 255, A: 255})
+	img.Set(0, 1, color.NRGBA{B: 255, A: 255})
+	img.Set(1, 1, color.NRGBA{G: 255, A: 255})
+	img.Set(2, 0, color.NRGBA{R: 1, G: 2, B: 3, A: 255})
+	img.Set(2, 2, color.NRGBA{R: 9, G: 9, B: 9, A: 255})
+	expected := "\x1b[38;2;255;0;0m\x1b[48;2;0;0;255m▀" +
+		"\x1b[0m\x1b[38;2;0;255;0m▄" +
+		"\x1b[0m\x1b[38;2;1;2;3m▀" +
+		"\x1b[0m\n" +
+		"\x1b[0m " +
+		"\x1b[0m " +
+		"\x1b[0m\x1b[38;2;9;9;9m▀" +
+		"\x1b[0m\n"
+	if got := string(EncodeANSI(img)); got != expected {
+		t.Errorf("Expected %q but got %q", expected, got)
+	}
+}
@@ -9,10 +9,15 @@ import (
 
 type ImageFormat string
 
-func ConvertSvgToBinary(svgData []byte, format ImageFormat, size uint) ([]byte, error) {
+// Sizes packed into an ICO, browsers pick the closest one
+var IcoSizes = []uint{16, 32, 48, 64}
+
+// readSvg - rasterize svg at size x size, the caller must destroy the returned wand
+func readSvg(svgData []byte, size uint) (*imagick.MagickWand, error) {
 	mw := imagick.NewMagickWand()
 	mw.SetImageFormat("SVG")
 	pixelWand := imagick.NewPixelWand()
+	defer pixelWand.Destroy()
 	pixelWand.SetColor("none")
 	mw.SetBackgroundColor(pixelWand)
 	mw.SetImageUnits(imagick.RESOLUTION_PIXELS_PER_INCH)
@@ -20,11 +25,59 @@ func ConvertSvgToBinary(svgData []byte, format ImageFormat, size uint) ([]byte,
 	mw.SetResolution(density, density)
 	err := mw.ReadImageBlob(svgData)
 	if err != nil {
+		mw.Destroy()
 		return nil, err
 	}
-	mw.SetImageCompression(imagick.COMPRESSION_NO)
-	mw.SetImageCompressionQuality(100)
+	// Density rounding can be a pixel off
+	if mw.GetImageWidth() != size || mw.GetImageHeight() != size {
+		if err := mw.ResizeImage(size, size, imagick.FILTER_LANCZOS); err != nil {
+			mw.Destroy()
+			return nil, err
+		}
+	}
+	return mw, nil
+}
+
+func ConvertSvgToBinary(svgData []byte, format ImageFormat, size uint) ([]byte, error) {
+	mw, err := readSvg(svgData, size)
+	if err != nil {
+		return nil, err
+	}
+	defer mw.Destroy()
+	if format == "jpeg" {
+		// No transparency in JPEG, flatten onto white
+		white := imagick.NewPixelWand()
+		defer white.Destroy()
+		white.SetColor("white")
+		mw.SetImageBackgroundColor(white)
+		mw.SetImageAlphaChannel(imagick.ALPHA_CHANNEL_REMOVE)
+		mw.SetImageCompressionQuality(92)
+	} else {
+		mw.SetImageCompression(imagick.COMPRESSION_NO)
+		mw.SetImageCompressionQuality(100)
+	}
 	//mw.SetAntialias(true)
 	mw.SetImageFormat(strings.ToUpper(string(format)))
 	return mw.GetImageBlob(), nil
 }
+
+// ConvertSvgToIco - a single ICO holding svgs[i] rasterized at sizes[i]
+func ConvertSvgToIco(svgs [][]byte, sizes []uint) ([]byte, error) {
+	ico := imagick.NewMagickWand()
+	defer ico.Destroy()
+	for i, size := range sizes {
+		mw, err := readSvg(svgs[i], size)
+		if err != nil {
+			return nil, err
+		}
+		mw.SetImageFormat("ICO")
+		err = ico.AddImage(mw)
+		mw.Destroy()
+		if err != nil {
+			return nil, err
+		}
+	}
+	ico.ResetIterator()
+	ico.SetImageFormat("ICO")
+	return ico.GetImagesBlob(), nil
+}
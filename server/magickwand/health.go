@@ -0,0 +1,52 @@
+package magickwand
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"gopkg.in/gographics/imagick.v3/imagick"
+)
+
+// Formats ImageMagick has to be built with for conversion to work
+var requiredFormats = []string{"SVG", "PNG", "WEBP"}
+
+// Formats served only when ImageMagick happens to support them
+var optionalFormats = []string{"AVIF", "JPEG", "ICO"}
+
+var formatsErr error
+var supported map[string]bool
+var formatsOnce sync.Once
+
+func queryFormats() {
+	formatsOnce.Do(func() {
+		mw := imagick.NewMagickWand()
+		defer mw.Destroy()
+		supported = map[string]bool{}
+		for _, format := range append(requiredFormats, optionalFormats...) {
+			supported[format] = len(mw.QueryFormats(format)) > 0
+		}
+		for _, format := range requiredFormats {
+			if !supported[format] {
+				formatsErr = fmt.Errorf("ImageMagick has no %s support", format)
+				return
+			}
+		}
+	})
+}
+
+// CheckFormats - returns an error if ImageMagick lacks a format needed for conversion
+func CheckFormats() error {
+	queryFormats()
+	return formatsErr
+}
+
+// Supports - whether ImageMagick can write format, AVIF needs a build with libheif
+func Supports(format string) bool {
+	queryFormats()
+	// Terminal output is drawn from a PNG
+	if format == "ansi" {
+		format = "PNG"
+	}
+	return supported[strings.ToUpper(format)]
+}
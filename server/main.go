@@ -1,54 +1,120 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"io/ioutil"
 	"math/rand"
+	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
+	"github.com/paw-digital/Pawnimals/server/config"
 	"github.com/paw-digital/Pawnimals/server/controller"
+	"github.com/paw-digital/Pawnimals/server/db"
 	"github.com/paw-digital/Pawnimals/server/image"
+	"github.com/paw-digital/Pawnimals/server/magickwand"
+	"github.com/paw-digital/Pawnimals/server/metrics"
+	"github.com/paw-digital/Pawnimals/server/middleware"
 	"github.com/paw-digital/Pawnimals/server/net"
 	"github.com/paw-digital/Pawnimals/server/spc"
 	"github.com/paw-digital/Pawnimals/server/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/golang/glog"
 	socketio "github.com/googollee/go-socket.io"
+	"github.com/googollee/go-socket.io/engineio"
+	"github.com/googollee/go-socket.io/engineio/transport"
+	"github.com/googollee/go-socket.io/engineio/transport/polling"
+	"github.com/googollee/go-socket.io/engineio/transport/websocket"
 	"github.com/jasonlvhit/gocron"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"gopkg.in/gographics/imagick.v3/imagick"
 )
 
-func CorsMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		origin := c.Request.Header.Get("Origin")
-		if origin == "" {
-			origin = "https://natricon.com"
-		}
-		c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
-		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Accept, Authorization, Content-Type, Content-Length, X-CSRF-Token, Token, session, Origin, Host, Connection, Accept-Encoding, Accept-Language, X-Requested-With, ResponseType")
-
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
-			return
-		}
+// Headers browsers may send on cross origin requests
+var corsHeaders = []string{"Accept", "Authorization", "Content-Type", "Content-Length", "X-CSRF-Token", "Token", "session", "Origin", "Host", "Connection", "Accept-Encoding", "Accept-Language", "X-Requested-With", "ResponseType", "X-API-Key"}
 
-		c.Request.Header.Del("Origin")
+// corsPolicies - public API is open to any origin without credentials, admin is restricted to admin origins
+// and everything else, like socket.io, is restricted to the allowed origins
+func corsPolicies(cfg config.CORS) (middleware.CORSPolicy, []middleware.CORSRoute) {
+	fallback := middleware.CORSPolicy{
+		AllowOrigins:     cfg.AllowedOrigins,
+		AllowCredentials: true,
+		AllowMethods:     []string{"GET", "POST", "OPTIONS"},
+		AllowHeaders:     corsHeaders,
+		MaxAge:           time.Hour,
+	}
+	return fallback, []middleware.CORSRoute{
+		{
+			Prefix: "/api/v1/",
+			Policy: middleware.CORSPolicy{
+				AllowOrigins: []string{"*"},
+				AllowMethods: []string{"GET", "OPTIONS"},
+				AllowHeaders: corsHeaders,
+				MaxAge:       24 * time.Hour,
+			},
+		},
+		{
+			Prefix: "/admin/",
+			Policy: middleware.CORSPolicy{
+				AllowOrigins: cfg.AdminOrigins,
+				AllowMethods: []string{"GET", "OPTIONS"},
+				AllowHeaders: []string{"Authorization"},
+			},
+		},
+	}
+}
 
-		c.Next()
+// rateLimiter - raster conversions are much more expensive than SVGs so they cost more tokens
+func rateLimiter(cfg config.RateLimit, proxies middleware.TrustedProxies) middleware.RateLimiter {
+	keys := map[string]middleware.APIKey{}
+	for _, k := range cfg.APIKeys {
+		keys[k.Key] = middleware.APIKey{
+			Service: k.Service,
+			Quota:   middleware.Quota{Rate: k.Rate, Burst: k.Burst},
+		}
 	}
+	rl := middleware.RateLimiter{
+		Anonymous: middleware.Quota{Rate: cfg.Rate, Burst: cfg.Burst},
+		Keys:      keys,
+		Proxies:   proxies,
+		Cost: func(c *gin.Context) int {
+			// A pack costs as much as converting each of its rasters on its own
+			if strings.HasSuffix(c.Request.URL.Path, "/favicon-pack") {
+				return cfg.RasterCost * controller.FaviconPackConversions
+			}
+			format := strings.ToLower(c.Query("format"))
+			if format == "" || format == "svg" {
+				return 1
+			}
+			// An ico is rendered at every one of its sizes
+			if format == "ico" {
+				return cfg.RasterCost * len(magickwand.IcoSizes)
+			}
+			return cfg.RasterCost
+		},
+	}
+	if cfg.Enabled {
+		rl.Limiter = db.GetDB()
+	}
+	return rl
 }
 
+// How long in-flight requests get to finish on shutdown
+const shutdownTimeout = 20 * time.Second
+
 func RandFiles(count int, seed string) {
 	if _, err := os.Stat("randsvg"); os.IsNotExist(err) {
 		os.Mkdir("randsvg", os.FileMode(0755))
 	}
 	for i := 0; i < count; i++ {
 		address := utils.GenerateAddress()
-		sha256 := utils.AddressSha256(address, seed)
+		sha256 := utils.PKSha256(utils.AddressToPub(address), seed)
 
 		accessories, _ := image.GetAccessoriesForHash(sha256, spc.BTNone, false, nil)
 		svg, _ := image.CombineSVG(accessories)
@@ -57,27 +123,68 @@ func RandFiles(count int, seed string) {
 }
 
 func main() {
-	// Get seed from env
-	seed := utils.GetEnv("NATRICON_SEED", "0123456789")
-	// Parse server options
+	// Parse server options, everything else is in the config
 	loadFiles := flag.Bool("load-files", false, "Print assets as GO arrays")
+	validateAssets := flag.Bool("validate-assets", false, "Check assets/manifest.json against the illustrations and vanities")
 	testBodyDist := flag.Bool("test-bd", false, "Test body distribution")
 	testHairDist := flag.Bool("test-hd", false, "Test hair distribution")
+	testScheme := flag.Int("test-scheme", 0, "Report hue coverage and body/hair contrast of this color scheme")
 	randomFiles := flag.Int("rand-files", -1, "Generate this many random SVGs and output to randsvg folder")
-
-	serverHost := flag.String("host", "127.0.0.1", "Host to listen on")
-	serverPort := flag.Int("port", 8080, "Port to listen on")
-	rpcUrl := flag.String("rpc-url", "", "Optional URL to use for nano RPC Client")
-	wsUrl := flag.String("nano-ws-url", "", "Nano WS Url to use for tracking donation account")
+	rarityTable := flag.Int("rarity-table", -1, "Regenerate image/rarity_table.go from this many sample hashes per scheme")
+	render := flag.String("render", "", "Print the natricon of this address to the terminal")
+	renderWidth := flag.Int("render-width", magickwand.DefaultANSIWidth, "Width in columns of -render output")
+	renderStyle := flag.String("render-style", "", "Style of -render output, pixel or mono")
+	collisions := flag.Int("collisions", -1, "Compare this many random accounts and report the most alike natricons")
+	configFlags := config.RegisterFlags(flag.CommandLine)
 	flag.Parse()
 
+	cfg, err := config.Load(configFlags)
+	if err != nil {
+		glog.Fatalf("Invalid configuration: %s", err)
+	}
+	seed := cfg.Seed
+	gen := image.Generation{Scheme: image.Scheme(cfg.Scheme), CVDSafe: cfg.CVDSafe}
+	db.Configure(cfg.Redis.Host, cfg.Redis.Port, cfg.Redis.DB)
+
 	if *loadFiles {
 		LoadAssetsToArray()
 		return
+	} else if *validateAssets {
+		if n := ValidateAssets(); n > 0 {
+			glog.Fatalf("%d problems with the assets", n)
+		}
+		fmt.Println("Assets are valid")
+		return
 	} else if *randomFiles > 0 {
 		fmt.Printf("Generating %d files in ./randsvg", *randomFiles)
 		RandFiles(*randomFiles, seed)
 		return
+	} else if *rarityTable > 0 {
+		if err := WriteRarityTable(*rarityTable); err != nil {
+			glog.Fatalf("Rarity table failed: %s", err)
+		}
+		return
+	}
+
+	if *render != "" {
+		if *renderWidth < magickwand.MinANSIWidth || *renderWidth > magickwand.MaxANSIWidth {
+			glog.Fatalf("render-width must be between %d and %d", magickwand.MinANSIWidth, magickwand.MaxANSIWidth)
+		}
+		style, err := image.ParseStyle(*renderStyle)
+		if err != nil {
+			glog.Fatalf("Invalid render-style: %s", err)
+		}
+		imagick.Initialize()
+		defer imagick.Terminate()
+		if err := controller.RenderTerminal(os.Stdout, seed, gen, *render, *renderWidth, style); err != nil {
+			glog.Fatalf("Render failed: %s", err)
+		}
+		return
+	}
+
+	if *collisions > 0 {
+		controller.SearchCollisions(os.Stdout, seed, gen, *collisions)
+		return
 	}
 
 	if *testBodyDist {
@@ -86,24 +193,57 @@ func main() {
 	} else if *testHairDist {
 		controller.TestHairDistribution(seed)
 		return
+	} else if *testScheme > 0 {
+		s, err := image.ParseScheme(strconv.Itoa(*testScheme))
+		if err != nil {
+			glog.Fatalf("Invalid test-scheme: %s", err)
+		}
+		controller.TestSchemeDistribution(seed, image.Generation{Scheme: s, CVDSafe: cfg.CVDSafe})
+		return
 	}
 
 	var rpcClient *net.RPCClient
-	if *rpcUrl != "" {
-		glog.Infof("RPC Client configured at %s", *rpcUrl)
-		rpcClient = &net.RPCClient{Url: *rpcUrl}
+	if cfg.Nano.RPCURL != "" {
+		glog.Infof("RPC Client configured at %s", cfg.Nano.RPCURL)
+		rpcClient = &net.RPCClient{Url: cfg.Nano.RPCURL}
 	}
 
 	// Setup magickwand
 	imagick.Initialize()
 	defer imagick.Terminate()
 
+	// Shared by everything that has to stop on SIGINT/SIGTERM
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigc
+		stop()
+	}()
+
+	// Clients behind the load balancers are told apart by X-Forwarded-For
+	proxies, err := middleware.ParseTrustedProxies(cfg.Server.TrustedProxies)
+	if err != nil {
+		glog.Fatalf("Invalid trusted proxies %s", err)
+	}
+	if cfg.RateLimit.Enabled && len(proxies) == 0 {
+		glog.Warningf("Rate limiting without trusted proxies, clients behind a load balancer share its bucket")
+	}
+
 	// Setup router
-	router := gin.Default()
-	router.Use(CorsMiddleware())
+	router := gin.New()
+	router.Use(middleware.Logger(proxies), gin.Recovery())
+	corsFallback, corsRoutes := corsPolicies(cfg.CORS)
+	router.Use(middleware.CORS(corsFallback, corsRoutes...))
 
-	// Setup socket IO server
-	sio := socketio.NewServer(nil)
+	// Setup socket IO server, websocket upgrades follow the same origin allowlist
+	sio := socketio.NewServer(&engineio.Options{
+		Transports: []transport.Transport{
+			polling.Default,
+			&websocket.Transport{CheckOrigin: corsFallback.CheckOrigin},
+		},
+	})
 	sio.OnConnect("/", func(s socketio.Conn) error {
 		s.SetContext("")
 		s.Join("bcast")
@@ -119,26 +259,67 @@ func main() {
 	router.GET("/socket.io/*any", gin.WrapH(sio))
 	router.POST("/socket.io/*any", gin.WrapH(sio))
 
-	// Setup channel for stats processing job
-	statsChan := make(chan *gin.Context, 100)
+	// Setup queue for stats processing job
+	statsQueue := controller.NewStatsQueue(db.GetDB())
+	metrics.RegisterStatsQueue(statsQueue.Dropped, statsQueue.Failed)
 
 	// Setup natricon controller
+	controller.ConfigureRasterCache(cfg.Raster.CacheMB << 20)
 	natriconController := controller.NatriconController{
-		Seed:         seed,
-		StatsChannel: &statsChan,
+		Seed:       seed,
+		Stats:      statsQueue,
+		Raster:     cfg.Raster,
+		Generation: gen,
+		Proxies:    proxies,
 	}
 	// Setup nano controller
+	donationAccount := cfg.Nano.DonationAccount
+	// A bad account only turns off the donation websocket, the icons are still served
+	validAccount := utils.ValidateAddress(donationAccount)
+	if donationAccount != "" && !validAccount {
+		glog.Errorf("Invalid donation account %s, not tracking donations", donationAccount)
+	}
+	wsEnabled := cfg.Nano.WSURL != "" && validAccount
 	nanoController := controller.NanoController{
 		RPCClient:       rpcClient,
 		SIOServer:       sio,
-		DonationAccount: utils.GetEnv("DONATION_ACCOUNT", ""),
+		DonationAccount: donationAccount,
+		WalletID:        cfg.Nano.WalletID,
+		Donations:       cfg.Donations,
+	}
+	// Setup health controller
+	healthController := controller.HealthController{
+		WSEnabled: wsEnabled,
+	}
+	// Setup admin controller
+	adminController := controller.AdminController{
+		Config: cfg,
 	}
 
-	// V1 API
-	router.GET("/api/v1/nano", natriconController.GetNano)
-	router.GET("/api/v1/nano/nonce", natriconController.GetNonce)
+	// V1 API, rate limited per client IP or API key
+	api := router.Group("/api/v1", rateLimiter(cfg.RateLimit, proxies).Handle)
+	api.GET("/nano", natriconController.GetNano)
+	api.GET("/nano/nonce", natriconController.GetNonce)
+	api.GET("/nano/alt", natriconController.GetAltText)
+	api.GET("/nano/favicon-pack", natriconController.GetFaviconPack)
+	api.GET("/nano/similarity", natriconController.GetSimilarity)
+	api.GET("/nano/palette", natriconController.GetPalette)
+	api.GET("/nano/traits", natriconController.GetTraits)
 	// Stats
-	router.GET("/api/v1/nano/stats", controller.Stats)
+	api.GET("/nano/stats", controller.Stats)
+	api.GET("/nano/stats/history", controller.StatsHistory)
+	// Representatives
+	api.GET("/reps", controller.Reps)
+	// Prometheus
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	// Probes
+	router.GET("/healthz", healthController.Healthz)
+	router.GET("/readyz", healthController.Readyz)
+	// Admin, only when a token is configured
+	if cfg.AdminToken != "" {
+		admin := router.Group("/admin", adminController.RequireToken)
+		admin.GET("/config", adminController.GetConfig)
+	}
 	if gin.IsDebugging() {
 		// For testing
 		router.GET("/api/natricon", natriconController.GetNatricon)
@@ -147,38 +328,65 @@ func main() {
 	}
 
 	// Setup cron jobs
+	var cronStop chan bool
 	if !gin.IsDebugging() {
-		go func() {
-			// Checking missed donations
-			gocron.Every(30).Minutes().Do(nanoController.CheckMissedCallbacks)
-			// Updating principal rep requirement
-			gocron.Every(30).Minutes().Do(nanoController.UpdatePrincipalWeight)
-			// Update principal reps, this is heavier so dont do it so often
-			gocron.Every(30).Minutes().Do(nanoController.UpdatePrincipalReps)
-			<-gocron.Start()
-		}()
+		// Checking missed donations
+		gocron.Every(cfg.Cron.CheckMissedCallbacks).Minutes().Do(nanoController.CheckMissedCallbacks)
+		// Updating principal rep requirement
+		gocron.Every(cfg.Cron.UpdatePrincipalWeight).Minutes().Do(nanoController.UpdatePrincipalWeight)
+		// Update principal reps, this is heavier so dont do it so often
+		gocron.Every(cfg.Cron.UpdatePrincipalReps).Minutes().Do(nanoController.UpdatePrincipalReps)
+		cronStop = gocron.Start()
 	}
 
 	// Start Nano WS client
-	donationAccount := utils.GetEnv("DONATION_ACCOUNT", "")
-	if *wsUrl != "" && utils.ValidateAddress(donationAccount) {
+	wsDone := make(chan struct{})
+	if wsEnabled {
 		fmt.Printf("\r\nDonation account: %s\r\n", donationAccount)
-		go net.StartNanoWSClient(*wsUrl, donationAccount, nanoController.Callback)
+		go func() {
+			net.StartNanoWSClient(ctx, cfg.Nano.WSURL, donationAccount, nanoController.Callback)
+			close(wsDone)
+		}()
 	} else {
 		fmt.Printf("No donation account specified!\r\n")
+		close(wsDone)
 	}
-	
+
 	// Show wallet
-	wallet := utils.GetEnv("WALLET_ID", "")
-	if wallet != "" {
-		fmt.Printf("Wallet account specified: %s\r\n", wallet)
+	if cfg.Nano.WalletID != "" {
+		fmt.Printf("Wallet specified\r\n")
 	} else {
 		fmt.Printf("No wallet specified!\r\n")
 	}
 
-	// Start stats worker
-	go controller.StatsWorker(statsChan)
+	// Start stats worker, and move any stats left in the old format alongside it.
+	// The migration adds to the new counters, so it doesn't need the worker to wait, and shutdown never waits on it.
+	go statsQueue.StatsWorker()
+	go db.GetDB().MigrateStats()
+
+	srv := &http.Server{
+		Addr:    fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port),
+		Handler: router,
+	}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			glog.Fatalf("Server error %s", err)
+		}
+	}()
 
-	// Run on 8080
-	router.Run(fmt.Sprintf("%s:%d", *serverHost, *serverPort))
+	// Drain requests first so nothing else gets queued, then stop everything else
+	<-ctx.Done()
+	glog.Infof("Shutting down")
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancelShutdown()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		glog.Errorf("Error draining requests %s", err)
+	}
+	if cronStop != nil {
+		cronStop <- true
+		gocron.Clear()
+	}
+	statsQueue.Close()
+	<-wsDone
+	glog.Flush()
 }
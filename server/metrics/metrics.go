@@ -0,0 +1,188 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Prefix for all metrics
+const namespace = "pawnimal"
+
+var (
+	// Icon requests, labelled by output format and size class
+	iconRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "icon_request_duration_seconds",
+		Help:      "Time taken to serve an icon request",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"format", "size"})
+	// Time spent in each render stage, combine (SVG assembly) or convert (rasterization)
+	renderDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "render_duration_seconds",
+		Help:      "Time taken by each render stage",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"stage", "format"})
+	renderErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "render_errors_total",
+		Help:      "Render failures by stage",
+	}, []string{"stage"})
+	cacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_requests_total",
+		Help:      "Cache lookups by cache and result (hit or miss)",
+	}, []string{"cache", "result"})
+	// Nano websocket
+	wsConnected = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "nano_ws_connected",
+		Help:      "1 if the nano websocket is connected and subscribed",
+	})
+	wsReconnects = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "nano_ws_reconnects_total",
+		Help:      "Times the nano websocket had to be re-established",
+	})
+	confirmationsProcessed = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "confirmations_processed_total",
+		Help:      "Confirmations received from the nano websocket",
+	})
+	// Donations
+	refunds = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "refunds_total",
+		Help:      "Refunds by result (sent or failed)",
+	}, []string{"result"})
+	// Cron
+	cronLastSuccess = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "cron_last_success_timestamp_seconds",
+		Help:      "Unix time a cron job last completed successfully",
+	}, []string{"job"})
+	// Rate limiting
+	rateLimited = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limited_total",
+		Help:      "Requests rejected for exceeding their quota, by client type (ip or key)",
+	}, []string{"client"})
+)
+
+func init() {
+	prometheus.MustRegister(
+		iconRequestDuration,
+		renderDuration,
+		renderErrors,
+		cacheRequests,
+		wsConnected,
+		wsReconnects,
+		confirmationsProcessed,
+		refunds,
+		cronLastSuccess,
+		rateLimited,
+	)
+}
+
+// SizeClass - bucket raster sizes so the size label stays low cardinality. ansi is sized in columns, not pixels, so it has a class of its own
+func SizeClass(format string, size int) string {
+	switch {
+	case format == "ansi":
+		return "columns"
+	case size <= 0:
+		return "vector"
+	case size <= 64:
+		return "64"
+	case size <= 128:
+		return "128"
+	case size <= 256:
+		return "256"
+	case size <= 512:
+		return "512"
+	}
+	return "1000"
+}
+
+// ObserveIconRequest - record how long an icon request took since start
+func ObserveIconRequest(format string, size int, start time.Time) {
+	iconRequestDuration.WithLabelValues(format, SizeClass(format, size)).Observe(time.Since(start).Seconds())
+}
+
+// ObserveRender - record how long a render stage took since start
+func ObserveRender(stage string, format string, start time.Time) {
+	renderDuration.WithLabelValues(stage, format).Observe(time.Since(start).Seconds())
+}
+
+// RenderError - count a failed render stage
+func RenderError(stage string) {
+	renderErrors.WithLabelValues(stage).Inc()
+}
+
+// CacheHit - count a cache lookup
+func CacheHit(cache string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	cacheRequests.WithLabelValues(cache, result).Inc()
+}
+
+// SetWSConnected - update nano websocket connection state
+func SetWSConnected(connected bool) {
+	if connected {
+		wsConnected.Set(1)
+	} else {
+		wsConnected.Set(0)
+	}
+}
+
+// WSReconnected - count a nano websocket reconnect
+func WSReconnected() {
+	wsReconnects.Inc()
+}
+
+// ConfirmationProcessed - count a confirmation from the nano websocket
+func ConfirmationProcessed() {
+	confirmationsProcessed.Inc()
+}
+
+// RefundSent - count a refund that was sent
+func RefundSent() {
+	refunds.WithLabelValues("sent").Inc()
+}
+
+// RefundFailed - count a refund that could not be sent
+func RefundFailed() {
+	refunds.WithLabelValues("failed").Inc()
+}
+
+// CronSucceeded - mark a cron job as having just completed
+func CronSucceeded(job string) {
+	cronLastSuccess.WithLabelValues(job).SetToCurrentTime()
+}
+
+// RateLimited - count a request rejected by the rate limiter
+func RateLimited(client string) {
+	rateLimited.WithLabelValues(client).Inc()
+}
+
+// RegisterStatsQueue - expose the stats queue's dropped and failed event counters
+func RegisterStatsQueue(dropped func() uint64, failed func() uint64) {
+	prometheus.MustRegister(
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "stats_events_dropped_total",
+			Help:      "Stats events dropped because the queue was full",
+		}, func() float64 {
+			return float64(dropped())
+		}),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "stats_events_failed_total",
+			Help:      "Stats events lost to failed redis writes",
+		}, func() float64 {
+			return float64(failed())
+		}),
+	)
+}
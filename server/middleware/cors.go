@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CORSPolicy - which origins may call a set of routes and how
+type CORSPolicy struct {
+	AllowOrigins     []string // Exact origins, wildcard subdomains like https://*.natricon.com, or * for anyone
+	AllowCredentials bool
+	AllowMethods     []string
+	AllowHeaders     []string
+	MaxAge           time.Duration
+}
+
+// CORSRoute - policy applied to every path starting with Prefix
+type CORSRoute struct {
+	Prefix string
+	Policy CORSPolicy
+}
+
+// CORS - apply the policy of the first route matching the request path, or fallback if none do
+// Registered with router.Use so it also answers preflights for routes without an OPTIONS handler
+func CORS(fallback CORSPolicy, routes ...CORSRoute) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		policy := fallback
+		for _, r := range routes {
+			if strings.HasPrefix(c.Request.URL.Path, r.Prefix) {
+				policy = r.Policy
+				break
+			}
+		}
+		policy.handle(c)
+	}
+}
+
+func (p CORSPolicy) handle(c *gin.Context) {
+	origin := c.GetHeader("Origin")
+	preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+	anyOrigin := !p.AllowCredentials && p.allowsAny()
+
+	header := c.Writer.Header()
+	// Responses differ per origin unless everyone gets *
+	if !anyOrigin {
+		header.Add("Vary", "Origin")
+	}
+	if preflight {
+		header.Add("Vary", "Access-Control-Request-Method")
+		header.Add("Vary", "Access-Control-Request-Headers")
+	}
+
+	if origin == "" {
+		c.Next()
+		return
+	}
+	if !anyOrigin && !p.allows(origin) {
+		if preflight {
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+		// Let it through without CORS headers, the browser will block the response
+		c.Next()
+		return
+	}
+
+	if anyOrigin {
+		header.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		header.Set("Access-Control-Allow-Origin", origin)
+	}
+	if p.AllowCredentials {
+		header.Set("Access-Control-Allow-Credentials", "true")
+	}
+
+	if preflight {
+		header.Set("Access-Control-Allow-Methods", strings.Join(p.AllowMethods, ", "))
+		if len(p.AllowHeaders) > 0 {
+			header.Set("Access-Control-Allow-Headers", strings.Join(p.AllowHeaders, ", "))
+		}
+		if p.MaxAge > 0 {
+			header.Set("Access-Control-Max-Age", strconv.Itoa(int(p.MaxAge.Seconds())))
+		}
+		c.AbortWithStatus(http.StatusNoContent)
+		return
+	}
+	c.Next()
+}
+
+func (p CORSPolicy) allowsAny() bool {
+	for _, o := range p.AllowOrigins {
+		if o == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// allows - whether origin matches one of the allowed origins, * is never reflected with credentials
+func (p CORSPolicy) allows(origin string) bool {
+	for _, o := range p.AllowOrigins {
+		if o != "*" && MatchOrigin(o, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchOrigin - exact match, or a single * standing in for one or more subdomain labels
+func MatchOrigin(pattern string, origin string) bool {
+	pattern = strings.ToLower(pattern)
+	origin = strings.ToLower(origin)
+	idx := strings.Index(pattern, "*")
+	if idx == -1 {
+		return pattern == origin
+	}
+	prefix, suffix := pattern[:idx], pattern[idx+1:]
+	if len(origin) <= len(prefix)+len(suffix) || !strings.HasPrefix(origin, prefix) || !strings.HasSuffix(origin, suffix) {
+		return false
+	}
+	// Wildcard can't swallow the scheme, port or path
+	middle := origin[len(prefix) : len(origin)-len(suffix)]
+	return !strings.ContainsAny(middle, "/:")
+}
+
+// CheckOrigin - for websocket upgraders, same origin or no origin requests pass as well as allowed origins
+func (p CORSPolicy) CheckOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	return p.allowsAny() || p.allows(origin)
+}
@@ -0,0 +1,182 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func testRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(CORS(
+		CORSPolicy{
+			AllowOrigins:     []string{"https://natricon.com", "https://*.natricon.com"},
+			AllowCredentials: true,
+			AllowMethods:     []string{"GET", "POST", "OPTIONS"},
+			AllowHeaders:     []string{"Content-Type"},
+			MaxAge:           time.Hour,
+		},
+		CORSRoute{
+			Prefix: "/api/",
+			Policy: CORSPolicy{
+				AllowOrigins: []string{"*"},
+				AllowMethods: []string{"GET", "OPTIONS"},
+			},
+		},
+		CORSRoute{
+			Prefix: "/admin/",
+			Policy: CORSPolicy{
+				AllowMethods: []string{"GET", "OPTIONS"},
+			},
+		},
+	))
+	ok := func(c *gin.Context) { c.String(http.StatusOK, c.GetHeader("Origin")) }
+	router.GET("/api/icon", ok)
+	router.GET("/admin/config", ok)
+	router.GET("/socket", ok)
+	return router
+}
+
+func do(router *gin.Engine, method string, path string, origin string, preflight bool) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	if preflight {
+		req.Header.Set("Access-Control-Request-Method", "GET")
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestPreflightAllowed(t *testing.T) {
+	w := do(testRouter(), "OPTIONS", "/socket", "https://app.natricon.com", true)
+	if w.Code != http.StatusNoContent {
+		t.Errorf("Expected %d but got %d", http.StatusNoContent, w.Code)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "https://app.natricon.com" {
+		t.Errorf("Expected %s but got %s", "https://app.natricon.com", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
+		t.Errorf("Expected %s but got %s", "true", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Methods"); got != "GET, POST, OPTIONS" {
+		t.Errorf("Expected %s but got %s", "GET, POST, OPTIONS", got)
+	}
+	if got := w.Header().Get("Access-Control-Max-Age"); got != "3600" {
+		t.Errorf("Expected %s but got %s", "3600", got)
+	}
+	if vary := w.Header().Values("Vary"); len(vary) == 0 || vary[0] != "Origin" {
+		t.Errorf("Expected Vary: Origin but got %v", vary)
+	}
+}
+
+func TestPreflightRejected(t *testing.T) {
+	for _, origin := range []string{"https://evil.com", "https://natricon.com.evil.com", "http://natricon.com"} {
+		w := do(testRouter(), "OPTIONS", "/socket", origin, true)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("Expected %d for %s but got %d", http.StatusForbidden, origin, w.Code)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+			t.Errorf("Expected no allowed origin for %s but got %s", origin, got)
+		}
+	}
+}
+
+func TestDisallowedOriginNotReflected(t *testing.T) {
+	w := do(testRouter(), "GET", "/socket", "https://evil.com", false)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected %d but got %d", http.StatusOK, w.Code)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("Expected no allowed origin but got %s", got)
+	}
+	// Handlers still see the origin
+	if w.Body.String() != "https://evil.com" {
+		t.Errorf("Expected %s but got %s", "https://evil.com", w.Body.String())
+	}
+}
+
+func TestPublicRoute(t *testing.T) {
+	w := do(testRouter(), "OPTIONS", "/api/icon", "https://anywhere.org", true)
+	if w.Code != http.StatusNoContent {
+		t.Errorf("Expected %d but got %d", http.StatusNoContent, w.Code)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("Expected %s but got %s", "*", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+		t.Errorf("Expected no credentials but got %s", got)
+	}
+	w = do(testRouter(), "GET", "/api/icon", "https://anywhere.org", false)
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("Expected %s but got %s", "*", got)
+	}
+	for _, v := range w.Header().Values("Vary") {
+		if v == "Origin" {
+			t.Errorf("Expected no Vary: Origin for wildcard responses")
+		}
+	}
+}
+
+func TestAdminRouteLocked(t *testing.T) {
+	w := do(testRouter(), "OPTIONS", "/admin/config", "https://natricon.com", true)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected %d but got %d", http.StatusForbidden, w.Code)
+	}
+}
+
+func TestNoOrigin(t *testing.T) {
+	w := do(testRouter(), "GET", "/socket", "", false)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected %d but got %d", http.StatusOK, w.Code)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("Expected no allowed origin but got %s", got)
+	}
+	if got := w.Header().Get("Vary"); got != "Origin" {
+		t.Errorf("Expected %s but got %s", "Origin", got)
+	}
+}
+
+func TestMatchOrigin(t *testing.T) {
+	cases := []struct {
+		pattern string
+		origin  string
+		match   bool
+	}{
+		{"https://natricon.com", "https://natricon.com", true},
+		{"https://natricon.com", "https://NATRICON.com", true},
+		{"https://*.natricon.com", "https://natricon.com", false},
+		{"https://*.natricon.com", "https://a.b.natricon.com", true},
+		{"https://*.natricon.com", "https://evil.com/.natricon.com", false},
+		{"https://*.natricon.com", "https://evil.com:1.natricon.com", false},
+		{"https://*.natricon.com", "http://a.natricon.com", false},
+	}
+	for _, tc := range cases {
+		if got := MatchOrigin(tc.pattern, tc.origin); got != tc.match {
+			t.Errorf("Expected %v for %s against %s but got %v", tc.match, tc.origin, tc.pattern, got)
+		}
+	}
+}
+
+func TestCheckOrigin(t *testing.T) {
+	p := CORSPolicy{AllowOrigins: []string{"https://natricon.com"}}
+	req := httptest.NewRequest("GET", "http://api.natricon.com/socket.io/", nil)
+	if !p.CheckOrigin(req) {
+		t.Errorf("Expected request without origin to pass")
+	}
+	req.Header.Set("Origin", "https://natricon.com")
+	if !p.CheckOrigin(req) {
+		t.Errorf("Expected allowed origin to pass")
+	}
+	req.Header.Set("Origin", "https://evil.com")
+	if p.CheckOrigin(req) {
+		t.Errorf("Expected disallowed origin to fail")
+	}
+}
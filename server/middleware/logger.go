@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// API key query values, kept out of the access log
+var apiKeyQueryValue = regexp.MustCompile(`([?&]` + apiKeyQuery + `=)[^&]*`)
+
+// RedactAPIKey - path with the value of any api_key query parameter masked
+func RedactAPIKey(path string) string {
+	return apiKeyQueryValue.ReplaceAllString(path, "${1}REDACTED")
+}
+
+// Logger - gin's access log with API keys redacted and clients resolved through proxies
+func Logger(proxies TrustedProxies) gin.HandlerFunc {
+	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
+		var statusColor, methodColor, resetColor string
+		if param.IsOutputColor() {
+			statusColor = param.StatusCodeColor()
+			methodColor = param.MethodColor()
+			resetColor = param.ResetColor()
+		}
+		if param.Latency > time.Minute {
+			param.Latency = param.Latency - param.Latency%time.Second
+		}
+		return fmt.Sprintf("[GIN] %v |%s %3d %s| %13v | %15s |%s %-7s %s %#v\n%s",
+			param.TimeStamp.Format("2006/01/02 - 15:04:05"),
+			statusColor, param.StatusCode, resetColor,
+			param.Latency,
+			proxies.ClientIP(param.Request),
+			methodColor, param.Method, resetColor,
+			RedactAPIKey(param.Path),
+			param.ErrorMessage,
+		)
+	})
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRedactAPIKey(t *testing.T) {
+	tests := map[string]string{
+		"/api/v1/nano?address=nano_1&api_key=secret":  "/api/v1/nano?address=nano_1&api_key=REDACTED",
+		"/api/v1/nano?api_key=secret&format=png":      "/api/v1/nano?api_key=REDACTED&format=png",
+		"/api/v1/nano?address=nano_1&my_api_key=kept": "/api/v1/nano?address=nano_1&my_api_key=kept",
+		"/api/v1/nano": "/api/v1/nano",
+	}
+	for path, expected := range tests {
+		if got := RedactAPIKey(path); got != expected {
+			t.Errorf("Expected %s but got %s", expected, got)
+		}
+	}
+}
+
+func TestLoggerRedactsAPIKey(t *testing.T) {
+	var out bytes.Buffer
+	defaultWriter := gin.DefaultWriter
+	gin.DefaultWriter = &out
+	defer func() { gin.DefaultWriter = defaultWriter }()
+
+	gin.SetMode(gin.TestMode)
+	proxies, _ := ParseTrustedProxies([]string{"10.0.0.0/8"})
+	router := gin.New()
+	router.Use(Logger(proxies))
+	router.GET("/icon", func(c *gin.Context) { c.String(http.StatusOK, c.Query("api_key")) })
+	req := httptest.NewRequest("GET", "/icon?api_key=secret", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("X-Forwarded-For", "198.51.100.7")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Body.String() != "secret" {
+		t.Errorf("Expected the handler to still see the key but got %s", w.Body.String())
+	}
+	if strings.Contains(out.String(), "secret") || !strings.Contains(out.String(), "api_key=REDACTED") {
+		t.Errorf("Expected the key to be redacted in %s", out.String())
+	}
+	if !strings.Contains(out.String(), "198.51.100.7") {
+		t.Errorf("Expected the forwarded client in %s", out.String())
+	}
+}
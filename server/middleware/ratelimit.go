@@ -0,0 +1,175 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/paw-digital/Pawnimals/server/db"
+	"github.com/paw-digital/Pawnimals/server/metrics"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/glog"
+)
+
+// Context key holding the service identity of an authenticated API key
+const ServiceKey = "service"
+
+// Where API keys are read from, the query parameter is for embedding icons in <img> tags
+const (
+	apiKeyHeader = "X-API-Key"
+	apiKeyQuery  = "api_key"
+)
+
+// Quota - token bucket size and refill rate per second
+type Quota struct {
+	Rate  float64
+	Burst int
+}
+
+// APIKey - partner key, requests made with it are attributed to Service
+type APIKey struct {
+	Service string
+	Quota   Quota
+}
+
+// TrustedProxies - networks of the load balancers in front of the server, only they may set X-Forwarded-For
+type TrustedProxies []*net.IPNet
+
+// ParseTrustedProxies - networks of each IP or CIDR in proxies
+func ParseTrustedProxies(proxies []string) (TrustedProxies, error) {
+	ret := TrustedProxies{}
+	for _, p := range proxies {
+		if !strings.Contains(p, "/") {
+			ip := net.ParseIP(p)
+			if ip == nil {
+				return nil, &net.ParseError{Type: "IP address", Text: p}
+			}
+			ret = append(ret, &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(p)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, network)
+	}
+	return ret, nil
+}
+
+func (tp TrustedProxies) contains(ip net.IP) bool {
+	for _, network := range tp {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP - address r came from, X-Forwarded-For is followed back through trusted proxies only so clients
+// can't pick their own. gin 1.7 only applies its TrustedProxies in Engine.Run, which graceful shutdown can't use
+func (tp TrustedProxies) ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(strings.TrimSpace(r.RemoteAddr))
+	if err != nil {
+		return r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !tp.contains(ip) {
+		return host
+	}
+	// Each proxy appends the address it got the request from, the last untrusted one is the client
+	hops := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(hops[i]))
+		if hop == nil {
+			break
+		}
+		ip = hop
+		if !tp.contains(hop) {
+			break
+		}
+	}
+	return ip.String()
+}
+
+// Limiter - takes tokens from a named bucket, db.GetDB() in production
+type Limiter interface {
+	TakeTokens(bucket string, rate float64, burst int, cost int) (db.RateLimitResult, error)
+}
+
+// RateLimiter - token bucket per client IP, or per API key when one is given
+type RateLimiter struct {
+	Limiter   Limiter // nil only identifies API keys without limiting
+	Anonymous Quota
+	Keys      map[string]APIKey
+	Cost      func(c *gin.Context) int // Tokens a request costs, 1 if nil
+	Proxies   TrustedProxies           // Anonymous buckets are per direct peer unless it is one of these
+}
+
+// Handle - gin middleware, unknown keys are rejected and requests over quota get 429
+func (rl RateLimiter) Handle(c *gin.Context) {
+	var bucket string
+	var clientType string
+	quota := rl.Anonymous
+	key := c.GetHeader(apiKeyHeader)
+	if key == "" {
+		key = c.Query(apiKeyQuery)
+	}
+	if key != "" {
+		apiKey, ok := rl.Keys[key]
+		if !ok {
+			c.String(http.StatusUnauthorized, "Invalid API key")
+			c.Abort()
+			return
+		}
+		c.Set(ServiceKey, apiKey.Service)
+		quota = apiKey.Quota
+		// Keys are hashed so they never end up in redis
+		sum := sha256.Sum256([]byte(key))
+		bucket = "key:" + hex.EncodeToString(sum[:8])
+		clientType = "key"
+	} else {
+		bucket = "ip:" + rl.Proxies.ClientIP(c.Request)
+		clientType = "ip"
+	}
+
+	if rl.Limiter == nil {
+		c.Next()
+		return
+	}
+	cost := 1
+	if rl.Cost != nil {
+		cost = rl.Cost(c)
+	}
+	if cost > quota.Burst {
+		cost = quota.Burst
+	}
+	result, err := rl.Limiter.TakeTokens(bucket, quota.Rate, quota.Burst, cost)
+	if err != nil {
+		// Don't take the API down with redis
+		glog.Errorf("Rate limiter unavailable %s", err)
+		c.Next()
+		return
+	}
+
+	header := c.Writer.Header()
+	header.Set("X-RateLimit-Limit", strconv.Itoa(quota.Burst))
+	header.Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	header.Set("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+	if !result.Allowed {
+		metrics.RateLimited(clientType)
+		header.Set("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+		c.String(http.StatusTooManyRequests, "Rate limit exceeded")
+		c.Abort()
+		return
+	}
+	c.Next()
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,182 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/paw-digital/Pawnimals/server/db"
+	"github.com/gin-gonic/gin"
+)
+
+// fakeLimiter - buckets that never refill
+type fakeLimiter struct {
+	tokens map[string]int
+	err    error
+}
+
+func (f *fakeLimiter) TakeTokens(bucket string, rate float64, burst int, cost int) (db.RateLimitResult, error) {
+	if f.err != nil {
+		return db.RateLimitResult{}, f.err
+	}
+	tokens, ok := f.tokens[bucket]
+	if !ok {
+		tokens = burst
+	}
+	if tokens < cost {
+		return db.RateLimitResult{Remaining: tokens, RetryAfter: 2 * time.Second, Reset: 10 * time.Second}, nil
+	}
+	f.tokens[bucket] = tokens - cost
+	return db.RateLimitResult{Allowed: true, Remaining: tokens - cost, Reset: time.Second}, nil
+}
+
+func rateLimitRouter(limiter Limiter) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	rl := RateLimiter{
+		Limiter:   limiter,
+		Anonymous: Quota{Rate: 1, Burst: 2},
+		Keys: map[string]APIKey{
+			"partner": {Service: "natrium", Quota: Quota{Rate: 10, Burst: 20}},
+		},
+		Cost: func(c *gin.Context) int {
+			if c.Query("format") == "png" {
+				return 5
+			}
+			return 1
+		},
+	}
+	router := gin.New()
+	router.GET("/icon", rl.Handle, func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString(ServiceKey))
+	})
+	return router
+}
+
+func get(router *gin.Engine, path string, key string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", path, nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	if key != "" {
+		req.Header.Set("X-API-Key", key)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimitAnonymous(t *testing.T) {
+	router := rateLimitRouter(&fakeLimiter{tokens: map[string]int{}})
+	for i := 0; i < 2; i++ {
+		w := get(router, "/icon", "")
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected %d but got %d", http.StatusOK, w.Code)
+		}
+		if got := w.Header().Get("X-RateLimit-Limit"); got != "2" {
+			t.Errorf("Expected %s but got %s", "2", got)
+		}
+	}
+	w := get(router, "/icon", "")
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected %d but got %d", http.StatusTooManyRequests, w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("Expected %s but got %s", "2", got)
+	}
+	if got := w.Header().Get("X-RateLimit-Remaining"); got != "0" {
+		t.Errorf("Expected %s but got %s", "0", got)
+	}
+}
+
+func TestRateLimitAPIKey(t *testing.T) {
+	router := rateLimitRouter(&fakeLimiter{tokens: map[string]int{}})
+	// Raster costs 5, more than the anonymous burst but within the partner quota
+	w := get(router, "/icon?format=png", "partner")
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected %d but got %d", http.StatusOK, w.Code)
+	}
+	if w.Body.String() != "natrium" {
+		t.Errorf("Expected %s but got %s", "natrium", w.Body.String())
+	}
+	if got := w.Header().Get("X-RateLimit-Remaining"); got != "15" {
+		t.Errorf("Expected %s but got %s", "15", got)
+	}
+	// Query parameter works too
+	w = get(router, "/icon?api_key=partner", "")
+	if w.Body.String() != "natrium" {
+		t.Errorf("Expected %s but got %s", "natrium", w.Body.String())
+	}
+}
+
+func TestRateLimitInvalidKey(t *testing.T) {
+	router := rateLimitRouter(&fakeLimiter{tokens: map[string]int{}})
+	w := get(router, "/icon", "nope")
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected %d but got %d", http.StatusUnauthorized, w.Code)
+	}
+}
+
+func TestRateLimitFailsOpen(t *testing.T) {
+	router := rateLimitRouter(&fakeLimiter{err: errors.New("redis down")})
+	w := get(router, "/icon", "")
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected %d but got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestRateLimitDisabledStillIdentifies(t *testing.T) {
+	router := rateLimitRouter(nil)
+	w := get(router, "/icon", "partner")
+	if w.Code != http.StatusOK || w.Body.String() != "natrium" {
+		t.Errorf("Expected %d natrium but got %d %s", http.StatusOK, w.Code, w.Body.String())
+	}
+}
+
+func TestRateLimitIgnoresSpoofedForwardedFor(t *testing.T) {
+	router := rateLimitRouter(&fakeLimiter{tokens: map[string]int{}})
+	codes := []int{}
+	for _, forwarded := range []string{"1.1.1.1", "2.2.2.2", "3.3.3.3"} {
+		req := httptest.NewRequest("GET", "/icon", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.Header.Set("X-Forwarded-For", forwarded)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		codes = append(codes, w.Code)
+	}
+	// Same peer, same bucket of 2
+	if codes[2] != http.StatusTooManyRequests {
+		t.Errorf("Expected %d but got %d", http.StatusTooManyRequests, codes[2])
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	proxies, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.168.1.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		remote    string
+		forwarded string
+		expected  string
+	}{
+		{"203.0.113.9:1234", "1.1.1.1", "203.0.113.9"},
+		{"10.0.0.1:1234", "", "10.0.0.1"},
+		{"10.0.0.1:1234", "1.1.1.1, 198.51.100.7", "198.51.100.7"},
+		{"10.0.0.1:1234", "198.51.100.7, 192.168.1.1", "198.51.100.7"},
+		{"10.0.0.1:1234", "garbage, 198.51.100.7", "198.51.100.7"},
+		{"192.168.1.1:1234", "10.0.0.2", "10.0.0.2"},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest("GET", "/icon", nil)
+		req.RemoteAddr = test.remote
+		if test.forwarded != "" {
+			req.Header.Set("X-Forwarded-For", test.forwarded)
+		}
+		if got := proxies.ClientIP(req); got != test.expected {
+			t.Errorf("Expected %s but got %s for %s via %s", test.expected, got, test.forwarded, test.remote)
+		}
+	}
+	if _, err := ParseTrustedProxies([]string{"10.0.0"}); err == nil {
+		t.Errorf("Expected an error for 10.0.0")
+	}
+}
@@ -2,11 +2,10 @@ package net
 
 import (
 	"context"
-	"os"
-	"os/signal"
-	"syscall"
+	"sync/atomic"
 	"time"
 
+	"github.com/paw-digital/Pawnimals/server/metrics"
 	"github.com/golang/glog"
 	guuid "github.com/google/uuid"
 	"github.com/recws-org/recws"
@@ -26,9 +25,27 @@ type ConfirmationResponse struct {
 	Message map[string]interface{} `json:"message"`
 }
 
-func StartNanoWSClient(wsUrl string, account string, callback func(data ConfirmationResponse)) {
-	ctx, cancel := context.WithCancel(context.Background())
+// 1 while the websocket is connected and subscribed to confirmations
+var wsSubscribed int32
+
+// WSSubscribed - whether the nano websocket is currently subscribed
+func WSSubscribed() bool {
+	return atomic.LoadInt32(&wsSubscribed) == 1
+}
+
+func setSubscribed(subscribed bool) {
+	if subscribed {
+		atomic.StoreInt32(&wsSubscribed, 1)
+	} else {
+		atomic.StoreInt32(&wsSubscribed, 0)
+	}
+	metrics.SetWSConnected(subscribed)
+}
+
+// StartNanoWSClient - listen for confirmations on account until ctx is cancelled
+func StartNanoWSClient(ctx context.Context, wsUrl string, account string, callback func(data ConfirmationResponse)) {
 	sentSubscribe := false
+	subscribedBefore := false
 	ws := recws.RecConn{}
 	// Nano subscription request
 	subRequest := wsSubscribe{
@@ -43,30 +60,22 @@ func StartNanoWSClient(wsUrl string, account string, callback func(data Confirma
 		},
 	}
 	ws.Dial(wsUrl, nil)
-
-	sigc := make(chan os.Signal, 1)
-	signal.Notify(sigc,
-		syscall.SIGHUP,
-		syscall.SIGINT,
-		syscall.SIGTERM,
-		syscall.SIGQUIT)
-	defer func() {
-		signal.Stop(sigc)
-		cancel()
+	defer setSubscribed(false)
+	// Closing the connection unblocks a pending read
+	go func() {
+		<-ctx.Done()
+		ws.Close()
 	}()
 
 	for {
 		select {
-		case <-sigc:
-			cancel()
-			return
 		case <-ctx.Done():
-			go ws.Close()
 			glog.Infof("Websocket closed %s", ws.GetURL())
 			return
 		default:
 			if !ws.IsConnected() {
 				sentSubscribe = false
+				setSubscribed(false)
 				glog.Infof("Websocket disconnected %s", ws.GetURL())
 				time.Sleep(2 * time.Second)
 				continue
@@ -80,6 +89,11 @@ func StartNanoWSClient(wsUrl string, account string, callback func(data Confirma
 					continue
 				} else {
 					sentSubscribe = true
+					setSubscribed(true)
+					if subscribedBefore {
+						metrics.WSReconnected()
+					}
+					subscribedBefore = true
 				}
 			}
 
@@ -88,11 +102,13 @@ func StartNanoWSClient(wsUrl string, account string, callback func(data Confirma
 			if err != nil {
 				glog.Infof("Error: ReadJSON %s", ws.GetURL())
 				sentSubscribe = false
+				setSubscribed(false)
 				continue
 			}
 
 			// Trigger callback
 			callback(confMessage)
+			metrics.ConfirmationProcessed()
 			glog.Infof("Received callback WS hash %s", confMessage.Message["hash"])
 		}
 	}
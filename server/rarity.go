@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+
+	"github.com/paw-digital/Pawnimals/server/image"
+)
+
+// WriteRarityTable - sample trait frequencies under every scheme into image/rarity_table.go. The samples are the
+// hashes of 0 to samples-1 rather than random accounts, so regenerating only changes the table where traits changed
+func WriteRarityTable(samples int) error {
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	var b bytes.Buffer
+	b.WriteString("// Code generated by natricon -rarity-table. DO NOT EDIT.\n\npackage image\n\n")
+	b.WriteString("var rarityTables = map[Generation]RarityTable{\n")
+	var gens []image.Generation
+	for _, scheme := range image.Schemes {
+		gens = append(gens, image.Generation{Scheme: scheme}, image.Generation{Scheme: scheme, CVDSafe: true})
+	}
+	for _, gen := range gens {
+		i := 0
+		table, err := image.BuildRarityTable(gen, samples, func() string {
+			sum := sha256.Sum256([]byte(strconv.Itoa(i)))
+			i++
+			return hex.EncodeToString(sum[:])
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&b, "{Scheme: %d, CVDSafe: %t}: {\nSamples: %d,\nFrequencies: map[TraitKind]map[string]float64{\n", gen.Scheme, gen.CVDSafe, table.Samples)
+		for _, kind := range table.Kinds() {
+			fmt.Fprintf(&b, "%q: {\n", kind)
+			values := make([]string, 0, len(table.Frequencies[kind]))
+			for value := range table.Frequencies[kind] {
+				values = append(values, value)
+			}
+			sort.Strings(values)
+			for _, value := range values {
+				fmt.Fprintf(&b, "%q: %s,\n", value, strconv.FormatFloat(table.Frequencies[kind][value], 'g', 4, 64))
+			}
+			b.WriteString("},\n")
+		}
+		b.WriteString("},\n},\n")
+	}
+	b.WriteString("}\n")
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return err
+	}
+	output := path.Join(wd, "image", "rarity_table.go")
+	fmt.Printf("Writing %d samples per scheme and cvd_safe setting to %s\n", samples, output)
+	return ioutil.WriteFile(output, src, os.FileMode(0644))
+}
@@ -12,6 +12,7 @@ const (
 	BTDonor    BadgeType = "donor"
 	BTExchange BadgeType = "exchange"
 	BTNode     BadgeType = "node"
+	BTNodeTop  BadgeType = "node_top"
 	BTService  BadgeType = "service"
 )
 